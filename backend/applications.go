@@ -0,0 +1,468 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stages of an application. Rejected and accepted are final.
+const (
+	stageApplied     = "applied"
+	stageShortlisted = "shortlisted"
+	stageTest        = "test"
+	stageInterview   = "interview"
+	stageOffer       = "offer"
+	stageRejected    = "rejected"
+	stageAccepted    = "accepted"
+)
+
+// stageTransitions lists the stages an application may move to from each stage
+var stageTransitions = map[string][]string{
+	stageApplied:     {stageShortlisted, stageRejected},
+	stageShortlisted: {stageTest, stageInterview, stageRejected},
+	stageTest:        {stageInterview, stageRejected},
+	stageInterview:   {stageOffer, stageRejected},
+	stageOffer:       {stageAccepted, stageRejected},
+}
+
+func canMove(from, to string) bool {
+	for _, next := range stageTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Application is a student's application to a drive
+type Application struct {
+	ApplicationID int64     `gorm:"primaryKey;column:application_id" json:"application_id"`
+	DriveID       int64     `gorm:"column:drive_id" json:"drive_id"`
+	StudentID     string    `gorm:"column:student_id" json:"srn"`
+	Stage         string    `gorm:"column:stage" json:"stage"`
+	PackageLPA    *float64  `gorm:"column:package_lpa" json:"package_lpa,omitempty"`
+	AppliedAt     time.Time `gorm:"column:applied_at" json:"applied_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Application) TableName() string {
+	return "application"
+}
+
+// ApplicationEvent is one stage transition of an application. The first
+// event of every application has no from stage.
+type ApplicationEvent struct {
+	EventID       int64     `gorm:"primaryKey;column:event_id" json:"event_id"`
+	ApplicationID int64     `gorm:"column:application_id" json:"application_id"`
+	FromStage     string    `gorm:"column:from_stage" json:"from,omitempty"`
+	ToStage       string    `gorm:"column:to_stage" json:"to"`
+	At            time.Time `gorm:"column:at" json:"at"`
+	Actor         string    `gorm:"column:actor" json:"actor"`
+	Note          string    `gorm:"column:note" json:"note,omitempty"`
+}
+
+func (ApplicationEvent) TableName() string {
+	return "application_event"
+}
+
+func insertApplicationEvent(db *gorm.DB, e ApplicationEvent) error {
+	err := db.Exec(`INSERT INTO application_event (application_id, from_stage, to_stage, at, actor, note)
+		VALUES ($1, $2, $3, $4, $5, $6)`, e.ApplicationID, e.FromStage, e.ToStage, e.At, e.Actor, e.Note).Error
+	if err != nil {
+		return fmt.Errorf("could not record application event: %v", err)
+	}
+	return nil
+}
+
+// ApplicationView is an application with its drive and stage history
+type ApplicationView struct {
+	Application
+	StudentName string             `json:"student_name"`
+	CompanyName string             `json:"company_name"`
+	DriveTitle  string             `json:"drive_title"`
+	Events      []ApplicationEvent `gorm:"-" json:"events"`
+}
+
+// fetchApplications loads applications matching the condition, with their events
+func fetchApplications(db *gorm.DB, where string, args ...interface{}) ([]ApplicationView, error) {
+	views := []ApplicationView{}
+	err := db.Raw(`
+		SELECT a.*, s.name AS student_name, c.name AS company_name, d.title AS drive_title
+		FROM application a
+		JOIN student s ON s.student_id = a.student_id
+		JOIN drive d ON d.drive_id = a.drive_id
+		JOIN company c ON c.company_id = d.company_id
+		WHERE `+where+`
+		ORDER BY a.applied_at DESC, a.application_id DESC`, args...).Scan(&views).Error
+	if err != nil || len(views) == 0 {
+		return views, err
+	}
+	ids := make([]int64, len(views))
+	for i, v := range views {
+		ids[i] = v.ApplicationID
+	}
+	var events []ApplicationEvent
+	err = db.Raw("SELECT * FROM application_event WHERE application_id IN ? ORDER BY at, event_id", ids).Scan(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	byApplication := map[int64][]ApplicationEvent{}
+	for _, e := range events {
+		byApplication[e.ApplicationID] = append(byApplication[e.ApplicationID], e)
+	}
+	for i := range views {
+		views[i].Events = byApplication[views[i].ApplicationID]
+		if views[i].Events == nil {
+			views[i].Events = []ApplicationEvent{}
+		}
+	}
+	return views, nil
+}
+
+// applicationsClosed reports whether the deadline of the drive has passed
+func applicationsClosed(d Drive, now time.Time) bool {
+	if d.Deadline == "" {
+		return false
+	}
+	deadline, err := time.ParseInLocation(sessionDateLayout, d.Deadline, now.Location())
+	return err == nil && now.After(deadline.AddDate(0, 0, 1))
+}
+
+// ApplyToDrive implements POST /drives/{drive_id}/applications for the
+// logged in student. Students who do not meet the drive's rules are turned
+// away with the rules they fail.
+func ApplyToDrive(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	d, ok := driveFromURL(db, w, r)
+	if !ok {
+		return
+	}
+	srn := r.URL.Query().Get("srn")
+	now := time.Now()
+	if applicationsClosed(d, now) {
+		writeAPIError(w, http.StatusConflict, apiError{Error: "applications for this drive closed on " + d.Deadline})
+		return
+	}
+	students, err := fetchEligibilityInputs(db, srn)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	if len(students) == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "student not found"})
+		return
+	}
+	if unmet := unmetRules(d.Eligibility, students[0]); len(unmet) > 0 {
+		var errs []fieldError
+		for _, reason := range unmet {
+			errs = append(errs, fieldError{Field: "eligibility", Message: reason})
+		}
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "not eligible for this drive", Fields: errs})
+		return
+	}
+
+	var id int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(`INSERT INTO application (drive_id, student_id, stage, applied_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5) RETURNING application_id`, d.DriveID, srn, stageApplied, now, now).Scan(&id).Error
+		if err != nil {
+			return err
+		}
+		return insertApplicationEvent(tx, ApplicationEvent{
+			ApplicationID: id, ToStage: stageApplied, At: now, Actor: auditActor(r),
+		})
+	})
+	if isUniqueViolation(err) {
+		writeAPIError(w, http.StatusConflict, apiError{Error: "already applied to this drive"})
+		return
+	}
+	if err != nil {
+		log.Printf("failed to apply %s to drive %d: %v", srn, d.DriveID, err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to store application"})
+		return
+	}
+	views, err := fetchApplications(db, "a.application_id = ?", id)
+	if err != nil || len(views) == 0 {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/applications/%d", id))
+	writeJSON(w, http.StatusCreated, views[0])
+}
+
+// ListStudentApplications implements GET /students/{srn}/applications
+func ListStudentApplications(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	views, err := fetchApplications(db, "a.student_id = ?", srn)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// ListDriveApplications implements GET /drives/{drive_id}/applications,
+// optionally only those at ?stage=
+func ListDriveApplications(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	d, ok := driveFromURL(db, w, r)
+	if !ok {
+		return
+	}
+	where, args := "a.drive_id = ?", []interface{}{d.DriveID}
+	if stage := r.URL.Query().Get("stage"); stage != "" {
+		if _, known := stageTransitions[stage]; !known && stage != stageRejected && stage != stageAccepted {
+			writeAPIError(w, http.StatusBadRequest, apiError{Error: fmt.Sprintf("unknown stage %q", stage)})
+			return
+		}
+		where, args = where+" AND a.stage = ?", append(args, stage)
+	}
+	views, err := fetchApplications(db, where, args...)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// stageChange is the JSON body of POST /applications/{application_id}/stage
+type stageChange struct {
+	Stage      string   `json:"stage"`
+	Note       string   `json:"note"`
+	PackageLPA *float64 `json:"package_lpa"`
+}
+
+var (
+	errStageConflict  = errors.New("application changed stage meanwhile")
+	errAlreadyPlaced  = errors.New("student has already accepted another offer")
+	errStageForbidden = errors.New("students may only accept or decline an offer")
+)
+
+// moveApplication moves an application to the next stage and records the
+// transition. The stage is checked again in the UPDATE, so two concurrent
+// moves cannot both succeed, and the unique index on accepted applications
+// stops two concurrent accepts of one student.
+func moveApplication(db *gorm.DB, app Application, change stageChange, actor string) error {
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if change.Stage == stageAccepted {
+			placed, err := rowExists(tx, "SELECT 1 FROM application WHERE student_id = ? AND stage = ? AND application_id <> ?",
+				app.StudentID, stageAccepted, app.ApplicationID)
+			if err != nil {
+				return err
+			}
+			if placed {
+				return errAlreadyPlaced
+			}
+		}
+		res := tx.Exec("UPDATE application SET stage = ?, package_lpa = COALESCE(?, package_lpa), updated_at = ? WHERE application_id = ? AND stage = ?",
+			change.Stage, change.PackageLPA, now, app.ApplicationID, app.Stage)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errStageConflict
+		}
+		return insertApplicationEvent(tx, ApplicationEvent{
+			ApplicationID: app.ApplicationID,
+			FromStage:     app.Stage,
+			ToStage:       change.Stage,
+			At:            now,
+			Actor:         actor,
+			Note:          change.Note,
+		})
+	})
+	if change.Stage == stageAccepted && isUniqueViolation(err) {
+		return errAlreadyPlaced
+	}
+	return err
+}
+
+// MoveApplication implements POST /applications/{application_id}/stage.
+// Mentors move candidates through every stage, a student may only accept or
+// decline their own offer. Moving to offer takes the drive's package unless
+// package_lpa is given.
+func MoveApplication(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromURL(w, r, "application_id")
+	if !ok {
+		return
+	}
+	var change stageChange
+	if !decodeJSONBody(w, r, &change) {
+		return
+	}
+	change.Stage = strings.ToLower(strings.TrimSpace(change.Stage))
+	change.Note = strings.TrimSpace(change.Note)
+
+	var app Application
+	res := db.Raw("SELECT * FROM application WHERE application_id = ?", id).Scan(&app)
+	if res.Error != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	s, _ := sessionFromContext(r.Context())
+	if res.RowsAffected == 0 || (s.Role == roleStudent && s.Subject != app.StudentID) {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "application not found"})
+		return
+	}
+	if s.Role == roleStudent && app.Stage != stageOffer {
+		writeAPIError(w, http.StatusForbidden, apiError{Error: errStageForbidden.Error()})
+		return
+	}
+
+	var errs fieldErrors
+	if !canMove(app.Stage, change.Stage) {
+		errs.check("stage", fmt.Sprintf("cannot move from %s to %q, allowed: %s",
+			app.Stage, change.Stage, strings.Join(stageTransitions[app.Stage], ", ")))
+	}
+	if change.PackageLPA != nil && (change.Stage != stageOffer || s.Role != roleMentor) {
+		errs.check("package_lpa", "can only be set by a mentor when making an offer")
+	} else if change.PackageLPA != nil && *change.PackageLPA < 0 {
+		errs.check("package_lpa", "must not be negative")
+	}
+	if len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+	if change.Stage == stageOffer && change.PackageLPA == nil {
+		d, _, err := fetchDrive(db, app.DriveID)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+			return
+		}
+		change.PackageLPA = &d.PackageLPA
+	}
+
+	err := moveApplication(db, app, change, auditActor(r))
+	switch {
+	case errors.Is(err, errStageConflict), errors.Is(err, errAlreadyPlaced):
+		writeAPIError(w, http.StatusConflict, apiError{Error: err.Error()})
+		return
+	case err != nil:
+		log.Printf("failed to move application %d: %v", id, err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to move application"})
+		return
+	}
+	views, err := fetchApplications(db, "a.application_id = ?", id)
+	if err != nil || len(views) == 0 {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	writeJSON(w, http.StatusOK, views[0])
+}
+
+// median of the values, 0 when there are none
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// PlacementGroup is the placement outcome of a group of students
+type PlacementGroup struct {
+	Stream           string  `json:"stream,omitempty"`
+	Students         int     `json:"students"`
+	Placed           int     `json:"placed"`
+	PlacedPercentage float64 `json:"placed_percentage"`
+	MedianPackageLPA float64 `json:"median_package_lpa"`
+	HighestPackage   float64 `json:"highest_package_lpa"`
+}
+
+// PlacementStatistics summarises the applications of all students
+type PlacementStatistics struct {
+	PlacementGroup
+	Stages   map[string]int   `json:"stages"`
+	ByStream []PlacementGroup `json:"by_stream"`
+}
+
+// placementGroup sums up students, given the package of every placed one
+func placementGroup(stream string, students int, packages []float64) PlacementGroup {
+	g := PlacementGroup{Stream: stream, Students: students, Placed: len(packages), MedianPackageLPA: median(packages)}
+	if students > 0 {
+		g.PlacedPercentage = math.Round(float64(len(packages))*10000/float64(students)) / 100
+	}
+	for _, p := range packages {
+		g.HighestPackage = math.Max(g.HighestPackage, p)
+	}
+	return g
+}
+
+// fetchPlacementStatistics counts a student as placed once they accepted an
+// offer, at the package of that offer
+func fetchPlacementStatistics(db *gorm.DB) (PlacementStatistics, error) {
+	var stats PlacementStatistics
+	var students []struct {
+		Stream string
+		Count  int
+	}
+	err := db.Raw("SELECT stream, COUNT(*) AS count FROM student GROUP BY stream ORDER BY stream").Scan(&students).Error
+	if err != nil {
+		return stats, err
+	}
+	var placed []struct {
+		Stream     string
+		PackageLPA float64 `gorm:"column:package_lpa"`
+	}
+	err = db.Raw(`
+		SELECT s.stream, MAX(COALESCE(a.package_lpa, d.package_lpa)) AS package_lpa
+		FROM application a
+		JOIN student s ON s.student_id = a.student_id
+		JOIN drive d ON d.drive_id = a.drive_id
+		WHERE a.stage = ?
+		GROUP BY s.student_id, s.stream`, stageAccepted).Scan(&placed).Error
+	if err != nil {
+		return stats, err
+	}
+	var stages []struct {
+		Stage string
+		Count int
+	}
+	if err := db.Raw("SELECT stage, COUNT(*) AS count FROM application GROUP BY stage").Scan(&stages).Error; err != nil {
+		return stats, err
+	}
+
+	packagesByStream := map[string][]float64{}
+	var all []float64
+	for _, p := range placed {
+		packagesByStream[p.Stream] = append(packagesByStream[p.Stream], p.PackageLPA)
+		all = append(all, p.PackageLPA)
+	}
+	total := 0
+	stats.ByStream = []PlacementGroup{}
+	for _, s := range students {
+		total += s.Count
+		stats.ByStream = append(stats.ByStream, placementGroup(s.Stream, s.Count, packagesByStream[s.Stream]))
+	}
+	stats.PlacementGroup = placementGroup("", total, all)
+	stats.Stages = map[string]int{}
+	for _, s := range stages {
+		stats.Stages[s.Stage] = s.Count
+	}
+	return stats, nil
+}
+
+// GetPlacementStatistics implements GET /placementStatistics
+func GetPlacementStatistics(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	stats, err := fetchPlacementStatistics(db)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedOffers adds two drives of one company and an offer from each to PES1,
+// as applications 1 and 2
+func seedOffers(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	now := time.Now()
+	steps := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO company (company_id, name) VALUES (1, 'Acme')", nil},
+		{"INSERT INTO drive (drive_id, company_id, title) VALUES (1, 1, 'SDE'), (2, 1, 'SRE')", nil},
+		{`INSERT INTO application (application_id, drive_id, student_id, stage, applied_at, updated_at)
+			VALUES (1, 1, 'PES1', 'offer', ?, ?), (2, 2, 'PES1', 'offer', ?, ?)`, []interface{}{now, now, now, now}},
+	}
+	for _, step := range steps {
+		if err := db.Exec(step.query, step.args...).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCanMove(t *testing.T) {
+	stages := []string{stageApplied, stageShortlisted, stageTest, stageInterview, stageOffer, stageRejected, stageAccepted}
+	allowed := map[[2]string]bool{
+		{stageApplied, stageShortlisted}:   true,
+		{stageApplied, stageRejected}:      true,
+		{stageShortlisted, stageTest}:      true,
+		{stageShortlisted, stageInterview}: true,
+		{stageShortlisted, stageRejected}:  true,
+		{stageTest, stageInterview}:        true,
+		{stageTest, stageRejected}:         true,
+		{stageInterview, stageOffer}:       true,
+		{stageInterview, stageRejected}:    true,
+		{stageOffer, stageAccepted}:        true,
+		{stageOffer, stageRejected}:        true,
+	}
+	for _, from := range append(stages, "unknown") {
+		for _, to := range append(stages, "unknown") {
+			if got := canMove(from, to); got != allowed[[2]string{from, to}] {
+				t.Errorf("canMove(%s, %s) = %v", from, to, got)
+			}
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{12}, 12},
+		{[]float64{30, 10, 20}, 20},
+		{[]float64{8, 4, 10, 6}, 7},
+	}
+	for _, tt := range tests {
+		if got := median(tt.values); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestMoveApplication(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	seedOffers(t, db)
+	accept := stageChange{Stage: stageAccepted}
+	offer := func(id int64) Application {
+		return Application{ApplicationID: id, StudentID: "PES1", Stage: stageOffer}
+	}
+
+	if err := moveApplication(db, offer(1), accept, "student:PES1"); err != nil {
+		t.Fatalf("accepting the first offer: %v", err)
+	}
+	if err := moveApplication(db, offer(1), stageChange{Stage: stageRejected}, "mentor:1"); !errors.Is(err, errStageConflict) {
+		t.Errorf("moving from a stale stage = %v, want errStageConflict", err)
+	}
+	if err := moveApplication(db, offer(2), accept, "student:PES1"); !errors.Is(err, errAlreadyPlaced) {
+		t.Errorf("accepting a second offer = %v, want errAlreadyPlaced", err)
+	}
+	if err := moveApplication(db, offer(2), stageChange{Stage: stageRejected}, "student:PES1"); err != nil {
+		t.Errorf("declining the second offer: %v", err)
+	}
+
+	var events []ApplicationEvent
+	if err := db.Raw("SELECT * FROM application_event ORDER BY event_id").Scan(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].ToStage != stageAccepted || events[1].ToStage != stageRejected || events[1].FromStage != stageOffer {
+		t.Errorf("events = %+v, want the accept and the decline", events)
+	}
+}
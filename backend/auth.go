@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// StudentCredential stores the bcrypt hash of a student's password
+type StudentCredential struct {
+	StudentID    string    `gorm:"primaryKey;column:student_id"`
+	PasswordHash string    `gorm:"column:password_hash"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+func (StudentCredential) TableName() string {
+	return "student_credentials"
+}
+
+// Session is the payload of a signed session token
+type Session struct {
+	Subject string `json:"sub"`
+	Role    string `json:"role"`
+	Expires int64  `json:"exp"`
+}
+
+const (
+	roleStudent = "student"
+	sessionTTL  = 12 * time.Hour
+)
+
+var errInvalidSession = errors.New("invalid or expired session")
+
+// sessionSecret is the HMAC key used to sign session tokens
+var sessionSecret []byte
+
+// initSessionSecret sets the signing key from the config. Without one a random
+// key is generated, so tokens stop working after a restart.
+func initSessionSecret(secret string) {
+	if secret != "" {
+		sessionSecret = []byte(secret)
+		return
+	}
+	sessionSecret = make([]byte, 32)
+	if _, err := rand.Read(sessionSecret); err != nil {
+		log.Fatalf("could not generate session secret: %v", err)
+	}
+	log.Println("Warning: no session secret is configured, sessions will not survive a restart")
+}
+
+func signSession(s Session) (string, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, sessionSecret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifySession(token string) (Session, error) {
+	var s Session
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return s, errInvalidSession
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil {
+		return s, errInvalidSession
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil {
+		return s, errInvalidSession
+	}
+	mac := hmac.New(sha256.New, sessionSecret)
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return s, errInvalidSession
+	}
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return s, errInvalidSession
+	}
+	if time.Now().Unix() > s.Expires {
+		return s, errInvalidSession
+	}
+	return s, nil
+}
+
+type sessionKey struct{}
+
+// sessionFromContext returns the session attached by the auth middleware
+func sessionFromContext(ctx context.Context) (Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(Session)
+	return s, ok
+}
+
+// authenticate verifies the bearer token of the request and checks its role
+func authenticate(r *http.Request, role string) (Session, int, error) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return Session{}, http.StatusUnauthorized, errors.New("missing bearer token")
+	}
+	s, err := verifySession(token)
+	if err != nil {
+		return s, http.StatusUnauthorized, err
+	}
+	if s.Role != role {
+		return s, http.StatusForbidden, errors.New("not allowed for this account")
+	}
+	return s, http.StatusOK, nil
+}
+
+// requireStudent only lets a logged in student through, and only for their
+// own SRN. A request without an srn parameter gets the student's SRN filled in.
+func requireStudent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, status, err := authenticate(r, roleStudent)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		query := r.URL.Query()
+		srn := query.Get("srn")
+		if srn == "" {
+			query.Set("srn", s.Subject)
+			r.URL.RawQuery = query.Encode()
+		} else if srn != s.Subject {
+			http.Error(w, "cannot access another student's data", http.StatusForbidden)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+	}
+}
+
+// requireStudentOrMentor lets a logged in student or mentor through. Which
+// students they may touch is left to the handler.
+func requireStudentOrMentor(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, status, err := authenticate(r, roleStudent)
+		if status == http.StatusForbidden {
+			s, status, err = authenticate(r, roleMentor)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+	}
+}
+
+// dummyHash is compared against when the SRN is unknown, so a failed login
+// takes as long for a missing account as for a wrong password
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("placify"), bcrypt.DefaultCost)
+
+// StudentLogin checks the SRN and password and issues a session token
+func StudentLogin(store Store, w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		SRN      string `json:"srn"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	creds.SRN = strings.ToUpper(strings.TrimSpace(creds.SRN))
+
+	cred, found, err := store.StudentCredential(creds.SRN)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	hash := []byte(cred.PasswordHash)
+	if !found {
+		hash = dummyHash
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(creds.Password)); err != nil || !found {
+		http.Error(w, "Invalid SRN or password", http.StatusUnauthorized)
+		return
+	}
+
+	expires := time.Now().Add(sessionTTL)
+	token, err := signSession(Session{Subject: cred.StudentID, Role: roleStudent, Expires: expires.Unix()})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	student, _, _ := store.Student(cred.StudentID)
+
+	response := map[string]interface{}{
+		"token":      token,
+		"srn":        cred.StudentID,
+		"name":       student.Name,
+		"expires_at": expires.UTC().Format(time.RFC3339),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// setStudentPassword stores a new bcrypt hash for the student
+func setStudentPassword(db *gorm.DB, srn, password string) error {
+	if len(password) < 8 {
+		return errors.New("password must be at least 8 characters")
+	}
+	exists, err := rowExists(db, "SELECT 1 FROM student WHERE student_id = ?", srn)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("student %s not found", srn)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return db.Exec(`
+		INSERT INTO student_credentials (student_id, password_hash, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (student_id) DO UPDATE SET password_hash = EXCLUDED.password_hash, updated_at = EXCLUDED.updated_at`,
+		srn, string(hash), time.Now()).Error
+}
+
+// passwdCommand implements `backend passwd (-srn SRN | -mentor ID) [-password pw]`.
+// The password is read from stdin when the flag is left out.
+func passwdCommand(cfg Config, args []string) {
+	fs := flag.NewFlagSet("passwd", flag.ExitOnError)
+	srn := fs.String("srn", "", "student SRN")
+	mentorID := fs.Int("mentor", 0, "mentor ID")
+	password := fs.String("password", "", "new password (read from stdin if empty)")
+	fs.Parse(args)
+
+	if (*srn == "") == (*mentorID == 0) {
+		log.Fatal("passwd: exactly one of -srn or -mentor is required")
+	}
+	if *password == "" {
+		fmt.Print("New password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Fatalf("passwd: could not read password: %v", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	db := connectDB(cfg.Database)
+	migrateDatabase(db)
+	var err error
+	if *srn != "" {
+		err = setStudentPassword(db, strings.ToUpper(*srn), *password)
+	} else {
+		err = setMentorPassword(db, *mentorID, *password)
+	}
+	if err != nil {
+		log.Fatalf("passwd: %v", err)
+	}
+	fmt.Println("password updated")
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	chatRoleUser      = "user"
+	chatRoleAssistant = "assistant"
+
+	// chatHistoryLimit is how many earlier messages are passed to the provider
+	chatHistoryLimit = 20
+	maxChatMessage   = 2000
+)
+
+// ChatMessage is one message of a conversation about a student
+type ChatMessage struct {
+	MessageID int64     `gorm:"primaryKey;column:message_id" json:"message_id"`
+	StudentID string    `gorm:"column:student_id" json:"srn"`
+	Actor     string    `gorm:"column:actor" json:"-"`
+	Role      string    `gorm:"column:role" json:"role"`
+	Content   string    `gorm:"column:content" json:"content"`
+	At        time.Time `gorm:"column:at" json:"at"`
+}
+
+func (ChatMessage) TableName() string {
+	return "chat_message"
+}
+
+// ChatRepository is a repository as the chatbot sees it
+type ChatRepository struct {
+	Name        string `json:"name"`
+	Language    string `json:"language"`
+	Description string `json:"description"`
+}
+
+// ChatAdvice is one mentor session as the chatbot sees it
+type ChatAdvice struct {
+	Date   string `json:"date"`
+	Advice string `json:"advice"`
+}
+
+// StudentChatContext is the student data the chatbot answers from. It holds
+// the same data as GetInfo, GetLeetcode, GetStudentGithub and GetMentorSessions.
+type StudentChatContext struct {
+	SRN             string           `json:"srn"`
+	Name            string           `json:"name"`
+	CGPA            float64          `json:"cgpa"`
+	Sem             int              `json:"sem"`
+	Degree          string           `json:"degree"`
+	Stream          string           `json:"stream"`
+	HasLeetCode     bool             `json:"has_leetcode"`
+	LeetCodeRanking int              `json:"leetcode_ranking"`
+	EasySolved      int              `json:"easy_solved"`
+	MediumSolved    int              `json:"medium_solved"`
+	HardSolved      int              `json:"hard_solved"`
+	Repositories    []ChatRepository `json:"repositories"`
+	MentorAdvice    []ChatAdvice     `json:"mentor_advice"`
+}
+
+// fetchStudentChatContext loads the chat context of a student. found is false
+// when there is no such student.
+func fetchStudentChatContext(db *gorm.DB, srn string) (c StudentChatContext, found bool, err error) {
+	res := db.Raw(`SELECT student_id AS srn, name, cgpa, sem, degree, stream
+		FROM student WHERE student_id = ?`, srn).Scan(&c)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return c, false, res.Error
+	}
+	var leetcode struct {
+		Ranking  int
+		NoEasy   int
+		NoMedium int
+		NoHard   int
+	}
+	res = db.Raw(`
+		SELECT l.ranking, COALESCE(p.no_easy, 0) AS no_easy, COALESCE(p.no_medium, 0) AS no_medium, COALESCE(p.no_hard, 0) AS no_hard
+		FROM leetcode l
+		LEFT JOIN problems p ON l.leetcode_id = p.leetcode_id
+		WHERE l.student_id = ?`, srn).Scan(&leetcode)
+	if res.Error != nil {
+		return c, true, res.Error
+	}
+	c.HasLeetCode = res.RowsAffected > 0
+	c.LeetCodeRanking = leetcode.Ranking
+	c.EasySolved, c.MediumSolved, c.HardSolved = leetcode.NoEasy, leetcode.NoMedium, leetcode.NoHard
+
+	err = db.Raw(`
+		SELECT r.repo_name AS name, r.language, r.description
+		FROM github g
+		JOIN repository r ON g.github_id = r.github_id
+		WHERE g.student_id = ?
+		ORDER BY r.repo_name`, srn).Scan(&c.Repositories).Error
+	if err != nil {
+		return c, true, err
+	}
+	err = db.Raw(`
+		SELECT `+dateText(db, "date")+` AS date, advice
+		FROM mentor_sessions
+		WHERE student_id = ?
+		ORDER BY date`, srn).Scan(&c.MentorAdvice).Error
+	return c, true, err
+}
+
+// ChatRequest is everything a provider gets to answer a message
+type ChatRequest struct {
+	Student StudentChatContext
+	History []ChatMessage
+	Message string
+}
+
+// ChatProvider answers a question about a student
+type ChatProvider interface {
+	Reply(ctx context.Context, req ChatRequest) (string, error)
+}
+
+// chatProvider answers the chat endpoint
+var chatProvider ChatProvider = ruleChatProvider{}
+
+// newChatProvider picks the configured provider: "rules" (the default) or
+// "openai" for any OpenAI compatible chat completions API
+func newChatProvider(cfg ChatConfig) (ChatProvider, error) {
+	switch cfg.Provider {
+	case "", "rules":
+		return ruleChatProvider{}, nil
+	case "openai":
+		if cfg.Model == "" {
+			return nil, errors.New("a model is required for the openai provider")
+		}
+		return newOpenAIChatProvider(cfg.URL, cfg.Model, cfg.APIKey), nil
+	default:
+		return nil, fmt.Errorf("unknown chat provider %q", cfg.Provider)
+	}
+}
+
+// ruleChatProvider answers from the student's data with fixed rules. It needs
+// no network and always gives the same answer to the same question.
+type ruleChatProvider struct{}
+
+// chatTopics are checked in order, the first topic with a matching keyword
+// answers
+var chatTopics = []struct {
+	keywords []string
+	answer   func(c StudentChatContext) string
+}{
+	{[]string{"cgpa", "gpa", "grade", "marks", "semester", "sem"}, chatAcademics},
+	{[]string{"leetcode", "problem", "dsa", "solved", "coding", "rank"}, chatLeetCode},
+	{[]string{"github", "repo", "project", "language"}, chatGithub},
+	{[]string{"mentor", "advice", "session", "feedback"}, chatMentorAdvice},
+	{[]string{"improve", "prepare", "placement", "ready", "next"}, chatSuggestions},
+	{[]string{"hello", "hi", "hey", "help"}, chatGreeting},
+}
+
+func (ruleChatProvider) Reply(ctx context.Context, req ChatRequest) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(req.Message), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	for _, topic := range chatTopics {
+		for _, keyword := range topic.keywords {
+			for _, word := range words {
+				if word == keyword || (len(keyword) > 3 && strings.HasPrefix(word, keyword)) {
+					return topic.answer(req.Student), nil
+				}
+			}
+		}
+	}
+	return chatSummary(req.Student), nil
+}
+
+func chatGreeting(c StudentChatContext) string {
+	return fmt.Sprintf("Hi! I can answer questions about %s's CGPA, LeetCode progress, GitHub projects and mentor advice.", c.Name)
+}
+
+func chatAcademics(c StudentChatContext) string {
+	return fmt.Sprintf("%s is in semester %d of %s %s with a CGPA of %.2f.", c.Name, c.Sem, c.Degree, c.Stream, c.CGPA)
+}
+
+func chatLeetCode(c StudentChatContext) string {
+	if !c.HasLeetCode {
+		return fmt.Sprintf("%s has not linked a LeetCode profile yet.", c.Name)
+	}
+	return fmt.Sprintf("%s has solved %d LeetCode problems (%d easy, %d medium, %d hard) and is ranked %d.",
+		c.Name, c.EasySolved+c.MediumSolved+c.HardSolved, c.EasySolved, c.MediumSolved, c.HardSolved, c.LeetCodeRanking)
+}
+
+func chatGithub(c StudentChatContext) string {
+	if len(c.Repositories) == 0 {
+		return fmt.Sprintf("%s has no GitHub repositories on record.", c.Name)
+	}
+	var repos []string
+	for _, r := range c.Repositories {
+		if r.Language != "" {
+			repos = append(repos, fmt.Sprintf("%s (%s)", r.Name, r.Language))
+		} else {
+			repos = append(repos, r.Name)
+		}
+	}
+	return fmt.Sprintf("%s has %d repositories on record: %s.", c.Name, len(c.Repositories), strings.Join(repos, ", "))
+}
+
+func chatMentorAdvice(c StudentChatContext) string {
+	if len(c.MentorAdvice) == 0 {
+		return fmt.Sprintf("%s has no mentor sessions yet.", c.Name)
+	}
+	latest := c.MentorAdvice[len(c.MentorAdvice)-1]
+	return fmt.Sprintf("%s has had %d mentor sessions. The latest, on %s, advised: %s",
+		c.Name, len(c.MentorAdvice), latest.Date, latest.Advice)
+}
+
+func chatSuggestions(c StudentChatContext) string {
+	var tips []string
+	if c.CGPA < 7 {
+		tips = append(tips, "raise the CGPA above 7, which many drives require")
+	}
+	if !c.HasLeetCode {
+		tips = append(tips, "link a LeetCode profile")
+	} else if c.MediumSolved+c.HardSolved < 100 {
+		tips = append(tips, "solve more medium and hard LeetCode problems")
+	}
+	if len(c.Repositories) < 3 {
+		tips = append(tips, "pin a few more GitHub projects")
+	}
+	if len(tips) == 0 {
+		return fmt.Sprintf("%s's profile looks placement ready. Keep practising and follow the mentor's advice.", c.Name)
+	}
+	return fmt.Sprintf("To improve, %s could %s.", c.Name, strings.Join(tips, ", "))
+}
+
+func chatSummary(c StudentChatContext) string {
+	return chatAcademics(c) + " " + chatLeetCode(c) + " " + chatGithub(c)
+}
+
+// openAIChatProvider talks to an OpenAI compatible chat completions API
+type openAIChatProvider struct {
+	httpClient *http.Client
+	endpoint   string
+	model      string
+	apiKey     string
+}
+
+func newOpenAIChatProvider(endpoint, model, apiKey string) *openAIChatProvider {
+	return &openAIChatProvider{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		model:      model,
+		apiKey:     apiKey,
+	}
+}
+
+const chatSystemPrompt = `You are the placement assistant of Placify. Answer questions about the student
+described by the JSON below, using only that data. Be brief. If the data does not answer the question, say so.`
+
+func (p *openAIChatProvider) Reply(ctx context.Context, req ChatRequest) (string, error) {
+	student, err := json.Marshal(req.Student)
+	if err != nil {
+		return "", err
+	}
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	messages := []message{{Role: "system", Content: chatSystemPrompt + "\n\n" + string(student)}}
+	for _, m := range req.History {
+		messages = append(messages, message{Role: m.Role, Content: m.Content})
+	}
+	messages = append(messages, message{Role: chatRoleUser, Content: req.Message})
+	payload, err := json.Marshal(map[string]interface{}{"model": p.model, "messages": messages})
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("chat provider: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chat provider: status %d", resp.StatusCode)
+	}
+	var result struct {
+		Choices []struct {
+			Message message `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("chat provider: parsing response: %v", err)
+	}
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return "", errors.New("chat provider: empty reply")
+	}
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
+
+// fetchChatHistory returns the latest messages of a conversation, oldest first
+func fetchChatHistory(db *gorm.DB, srn, actor string, limit int) ([]ChatMessage, error) {
+	history := []ChatMessage{}
+	err := db.Raw(`
+		SELECT * FROM (
+			SELECT * FROM chat_message
+			WHERE student_id = ? AND actor = ?
+			ORDER BY at DESC, message_id DESC
+			LIMIT ?
+		) latest
+		ORDER BY at, message_id`, srn, actor, limit).Scan(&history).Error
+	return history, err
+}
+
+// Chat implements POST /students/{srn}/chat. The student and their mentor each
+// have their own conversation about the student.
+func Chat(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if !decodeJSONBody(w, r, &body) {
+		return
+	}
+	body.Message = strings.TrimSpace(body.Message)
+	var errs fieldErrors
+	if body.Message == "" {
+		errs.check("message", "is required")
+	} else if len(body.Message) > maxChatMessage {
+		errs.check("message", fmt.Sprintf("must be at most %d characters", maxChatMessage))
+	}
+	if len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+
+	student, found, err := fetchStudentChatContext(db, srn)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	if !found {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "student not found"})
+		return
+	}
+	actor := auditActor(r)
+	history, err := fetchChatHistory(db, srn, actor, chatHistoryLimit)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+
+	asked := time.Now()
+	reply, err := chatProvider.Reply(r.Context(), ChatRequest{Student: student, History: history, Message: body.Message})
+	if err != nil {
+		log.Printf("chat provider failed for %s: %v", srn, err)
+		writeAPIError(w, http.StatusBadGateway, apiError{Error: "the assistant could not answer, try again later"})
+		return
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range []ChatMessage{
+			{StudentID: srn, Actor: actor, Role: chatRoleUser, Content: body.Message, At: asked},
+			{StudentID: srn, Actor: actor, Role: chatRoleAssistant, Content: reply, At: time.Now()},
+		} {
+			err := tx.Exec(`INSERT INTO chat_message (student_id, actor, role, content, at)
+				VALUES ($1, $2, $3, $4, $5)`, m.StudentID, m.Actor, m.Role, m.Content, m.At).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("could not store chat history of %s: %v", srn, err)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"reply": reply})
+}
+
+// GetChatHistory implements GET /students/{srn}/chat
+func GetChatHistory(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	history, err := fetchChatHistory(db, srn, auditActor(r), 200)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// ClearChatHistory implements DELETE /students/{srn}/chat
+func ClearChatHistory(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	if err := db.Exec("DELETE FROM chat_message WHERE student_id = ? AND actor = ?", srn, auditActor(r)).Error; err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to clear chat history"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
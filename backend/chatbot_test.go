@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var chatStudent = StudentChatContext{
+	SRN:             "PES1UG21CS001",
+	Name:            "Asha",
+	CGPA:            8.42,
+	Sem:             6,
+	Degree:          "BTech",
+	Stream:          "CSE",
+	HasLeetCode:     true,
+	LeetCodeRanking: 98412,
+	EasySolved:      151,
+	MediumSolved:    205,
+	HardSolved:      45,
+	Repositories:    []ChatRepository{{Name: "placify", Language: "Go, TypeScript"}},
+	MentorAdvice:    []ChatAdvice{{Date: "2024-01-10", Advice: "Practise graphs"}, {Date: "2024-02-14", Advice: "Work on system design"}},
+}
+
+func TestRuleChatProvider(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{"What is her CGPA?", "CGPA of 8.42"},
+		{"How many LeetCode problems has she solved?", "solved 401 LeetCode problems"},
+		{"Which projects are on GitHub?", "placify (Go, TypeScript)"},
+		{"What did the mentor say?", "Work on system design"},
+		{"hi", "I can answer questions about Asha"},
+		{"Tell me about her", "semester 6 of BTech CSE"},
+	}
+	for _, tt := range tests {
+		reply, err := ruleChatProvider{}.Reply(context.Background(), ChatRequest{Student: chatStudent, Message: tt.message})
+		if err != nil {
+			t.Fatalf("Reply(%q): %v", tt.message, err)
+		}
+		if !strings.Contains(reply, tt.want) {
+			t.Errorf("Reply(%q) = %q, want it to contain %q", tt.message, reply, tt.want)
+		}
+	}
+}
+
+func TestRuleChatProviderWithoutProfiles(t *testing.T) {
+	student := StudentChatContext{Name: "Ravi", CGPA: 6.5}
+	reply, _ := ruleChatProvider{}.Reply(context.Background(), ChatRequest{Student: student, Message: "how can I improve?"})
+	for _, want := range []string{"raise the CGPA", "link a LeetCode profile", "pin a few more GitHub projects"} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("reply %q does not contain %q", reply, want)
+		}
+	}
+}
+
+func TestOpenAIChatProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s, authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model != "small" || len(body.Messages) != 4 {
+			t.Errorf("model %q with %d messages, want small with 4", body.Model, len(body.Messages))
+		}
+		if len(body.Messages) > 0 && !strings.Contains(body.Messages[0].Content, `"srn":"PES1UG21CS001"`) {
+			t.Errorf("system prompt does not carry the student: %q", body.Messages[0].Content)
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":" Asha has a CGPA of 8.42. "}}]}`))
+	}))
+	defer srv.Close()
+
+	p := newOpenAIChatProvider(srv.URL+"/", "small", "key")
+	reply, err := p.Reply(context.Background(), ChatRequest{
+		Student: chatStudent,
+		History: []ChatMessage{{Role: chatRoleUser, Content: "hi"}, {Role: chatRoleAssistant, Content: "hello"}},
+		Message: "cgpa?",
+	})
+	if err != nil {
+		t.Fatalf("Reply: %v", err)
+	}
+	if reply != "Asha has a CGPA of 8.42." {
+		t.Errorf("reply = %q", reply)
+	}
+}
@@ -0,0 +1,526 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Company is a recruiter that runs placement drives
+type Company struct {
+	CompanyID   int64     `gorm:"primaryKey;column:company_id" json:"company_id"`
+	Name        string    `gorm:"column:name" json:"name"`
+	Website     string    `gorm:"column:website" json:"website"`
+	Description string    `gorm:"column:description" json:"description"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Company) TableName() string {
+	return "company"
+}
+
+// EligibilityRules decide which students may apply to a drive. Unset rules
+// do not restrict anyone.
+type EligibilityRules struct {
+	MinCGPA           *float64 `json:"min_cgpa,omitempty"`
+	Degrees           []string `json:"degrees"`
+	Streams           []string `json:"streams"`
+	MinSem            *int     `json:"min_sem,omitempty"`
+	MaxSem            *int     `json:"max_sem,omitempty"`
+	MinLeetCodeSolved *int     `json:"min_leetcode_solved,omitempty"`
+	RequiredLanguages []string `json:"required_languages"`
+}
+
+// Drive is a job posting of a company
+type Drive struct {
+	DriveID     int64            `json:"drive_id"`
+	CompanyID   int64            `json:"company_id"`
+	CompanyName string           `json:"company_name"`
+	Title       string           `json:"title"`
+	Role        string           `json:"role"`
+	PackageLPA  float64          `json:"package_lpa"`
+	Location    string           `json:"location"`
+	DriveDate   string           `json:"drive_date,omitempty"`
+	Deadline    string           `json:"deadline,omitempty"`
+	Eligibility EligibilityRules `json:"eligibility"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// driveRow is a drive as stored, with the list rules kept as JSON text
+type driveRow struct {
+	DriveID           int64
+	CompanyID         int64
+	CompanyName       string
+	Title             string
+	Role              string
+	PackageLPA        float64 `gorm:"column:package_lpa"`
+	Location          string
+	DriveDate         *string
+	Deadline          *string
+	MinCGPA           *float64 `gorm:"column:min_cgpa"`
+	Degrees           string
+	Streams           string
+	MinSem            *int
+	MaxSem            *int
+	MinLeetCodeSolved *int `gorm:"column:min_leetcode_solved"`
+	RequiredLanguages string
+	CreatedAt         time.Time
+}
+
+func (row driveRow) drive() Drive {
+	d := Drive{
+		DriveID:     row.DriveID,
+		CompanyID:   row.CompanyID,
+		CompanyName: row.CompanyName,
+		Title:       row.Title,
+		Role:        row.Role,
+		PackageLPA:  row.PackageLPA,
+		Location:    row.Location,
+		CreatedAt:   row.CreatedAt,
+		Eligibility: EligibilityRules{
+			MinCGPA:           row.MinCGPA,
+			MinSem:            row.MinSem,
+			MaxSem:            row.MaxSem,
+			MinLeetCodeSolved: row.MinLeetCodeSolved,
+		},
+	}
+	if row.DriveDate != nil {
+		d.DriveDate = *row.DriveDate
+	}
+	if row.Deadline != nil {
+		d.Deadline = *row.Deadline
+	}
+	json.Unmarshal([]byte(row.Degrees), &d.Eligibility.Degrees)
+	json.Unmarshal([]byte(row.Streams), &d.Eligibility.Streams)
+	json.Unmarshal([]byte(row.RequiredLanguages), &d.Eligibility.RequiredLanguages)
+	for _, list := range []*[]string{&d.Eligibility.Degrees, &d.Eligibility.Streams, &d.Eligibility.RequiredLanguages} {
+		if *list == nil {
+			*list = []string{}
+		}
+	}
+	return d
+}
+
+// companyPayload is the JSON body of POST and PUT /companies
+type companyPayload struct {
+	Name        string `json:"name"`
+	Website     string `json:"website"`
+	Description string `json:"description"`
+}
+
+func (p *companyPayload) validate() []fieldError {
+	var errs fieldErrors
+	p.Name = strings.TrimSpace(p.Name)
+	errs.check("name", checkName(p.Name))
+	if p.Website != "" {
+		if u, err := url.Parse(p.Website); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs.check("website", "must be an http(s) URL")
+		}
+	}
+	return errs
+}
+
+// idFromURL parses the numeric {name} variable of the route. It writes the
+// error response itself.
+func idFromURL(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil || id <= 0 {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func fetchCompany(db *gorm.DB, id int64) (Company, bool, error) {
+	var c Company
+	res := db.Raw("SELECT * FROM company WHERE company_id = ?", id).Scan(&c)
+	return c, res.RowsAffected > 0, res.Error
+}
+
+// isUniqueViolation reports whether err comes from a unique constraint
+func isUniqueViolation(err error) bool {
+	return err != nil && (errors.Is(err, gorm.ErrDuplicatedKey) ||
+		strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint"))
+}
+
+// ListCompanies implements GET /companies
+func ListCompanies(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	companies := []Company{}
+	if err := db.Raw("SELECT * FROM company ORDER BY name").Scan(&companies).Error; err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	writeJSON(w, http.StatusOK, companies)
+}
+
+// GetCompany implements GET /companies/{company_id}
+func GetCompany(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromURL(w, r, "company_id")
+	if !ok {
+		return
+	}
+	c, found, err := fetchCompany(db, id)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	if !found {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "company not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// CreateCompany implements POST /companies
+func CreateCompany(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	var p companyPayload
+	if !decodeJSONBody(w, r, &p) {
+		return
+	}
+	if errs := p.validate(); len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+	var id int64
+	err := db.Raw(`INSERT INTO company (name, website, description, created_at)
+		VALUES ($1, $2, $3, $4) RETURNING company_id`, p.Name, p.Website, p.Description, time.Now()).Scan(&id).Error
+	if isUniqueViolation(err) {
+		writeAPIError(w, http.StatusConflict, apiError{Error: fmt.Sprintf("company %q already exists", p.Name)})
+		return
+	}
+	if err != nil {
+		log.Printf("failed to create company: %v", err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to create company"})
+		return
+	}
+	c, _, _ := fetchCompany(db, id)
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// UpdateCompany implements PUT /companies/{company_id}
+func UpdateCompany(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromURL(w, r, "company_id")
+	if !ok {
+		return
+	}
+	var p companyPayload
+	if !decodeJSONBody(w, r, &p) {
+		return
+	}
+	if errs := p.validate(); len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+	res := db.Exec("UPDATE company SET name = ?, website = ?, description = ? WHERE company_id = ?",
+		p.Name, p.Website, p.Description, id)
+	if isUniqueViolation(res.Error) {
+		writeAPIError(w, http.StatusConflict, apiError{Error: fmt.Sprintf("company %q already exists", p.Name)})
+		return
+	}
+	if res.Error != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to update company"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "company not found"})
+		return
+	}
+	c, _, _ := fetchCompany(db, id)
+	writeJSON(w, http.StatusOK, c)
+}
+
+// DeleteCompany implements DELETE /companies/{company_id}. The company's
+// drives go with it.
+func DeleteCompany(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromURL(w, r, "company_id")
+	if !ok {
+		return
+	}
+	res := db.Exec("DELETE FROM company WHERE company_id = ?", id)
+	if res.Error != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to delete company"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "company not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// drivePayload is the JSON body of POST and PUT /drives
+type drivePayload struct {
+	CompanyID   int64            `json:"company_id"`
+	Title       string           `json:"title"`
+	Role        string           `json:"role"`
+	PackageLPA  float64          `json:"package_lpa"`
+	Location    string           `json:"location"`
+	DriveDate   string           `json:"drive_date"`
+	Deadline    string           `json:"deadline"`
+	Eligibility EligibilityRules `json:"eligibility"`
+}
+
+// cleanList trims the entries of a rule list and drops empty ones
+func cleanList(list []string) []string {
+	out := []string{}
+	for _, s := range list {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (p *drivePayload) validate() []fieldError {
+	var errs fieldErrors
+	if p.CompanyID <= 0 {
+		errs.check("company_id", "is required")
+	}
+	p.Title = strings.TrimSpace(p.Title)
+	errs.check("title", checkName(p.Title))
+	if p.PackageLPA < 0 {
+		errs.check("package_lpa", "must not be negative")
+	}
+	var driveDate, deadline time.Time
+	if p.DriveDate != "" {
+		var err error
+		if driveDate, err = time.Parse(sessionDateLayout, p.DriveDate); err != nil {
+			errs.check("drive_date", "must be a date like 2025-01-31")
+		}
+	}
+	if p.Deadline != "" {
+		var err error
+		if deadline, err = time.Parse(sessionDateLayout, p.Deadline); err != nil {
+			errs.check("deadline", "must be a date like 2025-01-31")
+		}
+	}
+	if !driveDate.IsZero() && !deadline.IsZero() && deadline.After(driveDate) {
+		errs.check("deadline", "must not be after the drive date")
+	}
+
+	e := &p.Eligibility
+	if e.MinCGPA != nil {
+		errs.check("eligibility.min_cgpa", checkCGPA(*e.MinCGPA))
+	}
+	if e.MinSem != nil {
+		errs.check("eligibility.min_sem", checkSem(*e.MinSem))
+	}
+	if e.MaxSem != nil {
+		errs.check("eligibility.max_sem", checkSem(*e.MaxSem))
+	}
+	if e.MinSem != nil && e.MaxSem != nil && *e.MinSem > *e.MaxSem {
+		errs.check("eligibility.max_sem", "must not be below min_sem")
+	}
+	if e.MinLeetCodeSolved != nil && *e.MinLeetCodeSolved < 0 {
+		errs.check("eligibility.min_leetcode_solved", "must not be negative")
+	}
+	e.Degrees = cleanList(e.Degrees)
+	e.Streams = cleanList(e.Streams)
+	e.RequiredLanguages = cleanList(e.RequiredLanguages)
+	return errs
+}
+
+// args returns the column values of the drive, in the order used by the
+// INSERT and UPDATE statements
+func (p *drivePayload) args() []interface{} {
+	nullDate := func(s string) interface{} {
+		if s == "" {
+			return nil
+		}
+		return s
+	}
+	degrees, _ := json.Marshal(p.Eligibility.Degrees)
+	streams, _ := json.Marshal(p.Eligibility.Streams)
+	languages, _ := json.Marshal(p.Eligibility.RequiredLanguages)
+	e := p.Eligibility
+	return []interface{}{
+		p.CompanyID, p.Title, p.Role, p.PackageLPA, p.Location, nullDate(p.DriveDate), nullDate(p.Deadline),
+		e.MinCGPA, string(degrees), string(streams), e.MinSem, e.MaxSem, e.MinLeetCodeSolved, string(languages),
+	}
+}
+
+func driveSelect(db *gorm.DB) string {
+	return `
+	SELECT d.drive_id, d.company_id, c.name AS company_name, d.title, d.role, d.package_lpa, d.location,
+		` + dateText(db, "d.drive_date") + ` AS drive_date, ` + dateText(db, "d.deadline") + ` AS deadline,
+		d.min_cgpa, d.degrees, d.streams, d.min_sem, d.max_sem, d.min_leetcode_solved, d.required_languages,
+		d.created_at
+	FROM drive d
+	JOIN company c ON c.company_id = d.company_id`
+}
+
+func fetchDrive(db *gorm.DB, id int64) (Drive, bool, error) {
+	var row driveRow
+	res := db.Raw(driveSelect(db)+" WHERE d.drive_id = ?", id).Scan(&row)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return Drive{}, false, res.Error
+	}
+	return row.drive(), true, nil
+}
+
+// driveFromURL loads the drive of the route. It writes the error response itself.
+func driveFromURL(db *gorm.DB, w http.ResponseWriter, r *http.Request) (Drive, bool) {
+	id, ok := idFromURL(w, r, "drive_id")
+	if !ok {
+		return Drive{}, false
+	}
+	d, found, err := fetchDrive(db, id)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return d, false
+	}
+	if !found {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "drive not found"})
+		return d, false
+	}
+	return d, true
+}
+
+// ListDrives implements GET /drives, optionally only of ?company_id=
+func ListDrives(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	query := driveSelect(db)
+	var args []interface{}
+	if v := r.URL.Query().Get("company_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid company_id"})
+			return
+		}
+		query += " WHERE d.company_id = ?"
+		args = append(args, id)
+	}
+	query += " ORDER BY d.drive_date DESC NULLS LAST, d.drive_id DESC"
+	var rows []driveRow
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	drives := []Drive{}
+	for _, row := range rows {
+		drives = append(drives, row.drive())
+	}
+	writeJSON(w, http.StatusOK, drives)
+}
+
+// GetDrive implements GET /drives/{drive_id}
+func GetDrive(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	d, ok := driveFromURL(db, w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// checkCompanyExists rejects a payload naming an unknown company. It writes
+// the error response itself.
+func checkCompanyExists(db *gorm.DB, w http.ResponseWriter, companyID int64) bool {
+	exists, err := rowExists(db, "SELECT 1 FROM company WHERE company_id = ?", companyID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return false
+	}
+	if !exists {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{
+			Error:  "validation failed",
+			Fields: []fieldError{{Field: "company_id", Message: "is not a known company"}},
+		})
+		return false
+	}
+	return true
+}
+
+// CreateDrive implements POST /drives
+func CreateDrive(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	var p drivePayload
+	if !decodeJSONBody(w, r, &p) {
+		return
+	}
+	if errs := p.validate(); len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+	if !checkCompanyExists(db, w, p.CompanyID) {
+		return
+	}
+	var id int64
+	err := db.Raw(`
+		INSERT INTO drive (company_id, title, role, package_lpa, location, drive_date, deadline,
+			min_cgpa, degrees, streams, min_sem, max_sem, min_leetcode_solved, required_languages, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING drive_id`, append(p.args(), time.Now())...).Scan(&id).Error
+	if err != nil {
+		log.Printf("failed to create drive: %v", err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to create drive"})
+		return
+	}
+	d, _, _ := fetchDrive(db, id)
+	writeJSON(w, http.StatusCreated, d)
+}
+
+// UpdateDrive implements PUT /drives/{drive_id}
+func UpdateDrive(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromURL(w, r, "drive_id")
+	if !ok {
+		return
+	}
+	var p drivePayload
+	if !decodeJSONBody(w, r, &p) {
+		return
+	}
+	if errs := p.validate(); len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+	if !checkCompanyExists(db, w, p.CompanyID) {
+		return
+	}
+	res := db.Exec(`
+		UPDATE drive SET company_id = ?, title = ?, role = ?, package_lpa = ?, location = ?, drive_date = ?,
+			deadline = ?, min_cgpa = ?, degrees = ?, streams = ?, min_sem = ?, max_sem = ?,
+			min_leetcode_solved = ?, required_languages = ?
+		WHERE drive_id = ?`, append(p.args(), id)...)
+	if res.Error != nil {
+		log.Printf("failed to update drive %d: %v", id, res.Error)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to update drive"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "drive not found"})
+		return
+	}
+	d, _, _ := fetchDrive(db, id)
+	writeJSON(w, http.StatusOK, d)
+}
+
+// DeleteDrive implements DELETE /drives/{drive_id}
+func DeleteDrive(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromURL(w, r, "drive_id")
+	if !ok {
+		return
+	}
+	res := db.Exec("DELETE FROM drive WHERE drive_id = ?", id)
+	if res.Error != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to delete drive"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "drive not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
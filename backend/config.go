@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written as a Go duration string such as "6h"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"6h\": %v", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// DatabaseConfig says which database to use. The sqlite driver keeps
+// everything in the file at Path and ignores the other fields. For postgres,
+// URL, when set, is used as the DSN as is and the other fields are ignored.
+type DatabaseConfig struct {
+	Driver   string `json:"driver"`
+	Path     string `json:"path"`
+	URL      string `json:"url"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	SSLMode  string `json:"sslmode"`
+	TimeZone string `json:"timezone"`
+}
+
+// dsnValue quotes a value for a key=value connection string
+func dsnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// DSN is the connection string for the configured driver
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == "sqlite" {
+		// Foreign keys are off by default in SQLite, and a writer waits for
+		// another one instead of failing at once. Transactions take the write
+		// lock when they begin, so what they read stays valid until commit.
+		return "file:" + c.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	}
+	if c.URL != "" {
+		return c.URL
+	}
+	parts := []string{
+		"host=" + dsnValue(c.Host),
+		"port=" + strconv.Itoa(c.Port),
+		"user=" + dsnValue(c.User),
+		"dbname=" + dsnValue(c.Name),
+		"sslmode=" + dsnValue(c.SSLMode),
+		"TimeZone=" + dsnValue(c.TimeZone),
+	}
+	if c.Password != "" {
+		parts = append(parts, "password="+dsnValue(c.Password))
+	}
+	return strings.Join(parts, " ")
+}
+
+// LeetCodeConfig picks the LeetCode provider, see newLeetCodeProvider
+type LeetCodeConfig struct {
+	Provider string `json:"provider"`
+	ProxyURL string `json:"proxy_url"`
+	Fixtures string `json:"fixtures"`
+}
+
+// ChatConfig picks the chat provider, see newChatProvider
+type ChatConfig struct {
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+	Model    string `json:"model"`
+	APIKey   string `json:"api_key"`
+}
+
+// Config is everything that differs between a laptop, CI and the placement
+// cell's server. It is read from a JSON file, then environment variables
+// override single settings.
+type Config struct {
+	Listen             string         `json:"listen"`
+	AllowedOrigins     []string       `json:"allowed_origins"`
+	Database           DatabaseConfig `json:"database"`
+	SessionSecret      string         `json:"session_secret"`
+	GithubToken        string         `json:"github_token"`
+	LeetCode           LeetCodeConfig `json:"leetcode"`
+	Chat               ChatConfig     `json:"chat"`
+	ResumeDir          string         `json:"resume_dir"`
+	PublicKeyPath      string         `json:"public_key_path"`
+	ScoreWeightsPath   string         `json:"score_weights_path"`
+	SkillsPath         string         `json:"skills_path"`
+	RefreshInterval    Duration       `json:"refresh_interval"`
+	RefreshConcurrency int            `json:"refresh_concurrency"`
+	DeleteRetention    Duration       `json:"delete_retention"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Listen:         ":8000",
+		AllowedOrigins: []string{"*"},
+		Database: DatabaseConfig{
+			Driver:   "postgres",
+			Path:     "placify.db",
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Name:     "dbms_project",
+			SSLMode:  "disable",
+			TimeZone: "UTC",
+		},
+		LeetCode:           LeetCodeConfig{Provider: "graphql"},
+		Chat:               ChatConfig{Provider: "rules", URL: "https://api.openai.com/v1"},
+		ResumeDir:          "resumes",
+		PublicKeyPath:      "keys/public_key.pem",
+		ScoreWeightsPath:   "score_weights.json",
+		SkillsPath:         "skills.json",
+		RefreshInterval:    Duration(6 * time.Hour),
+		RefreshConcurrency: 4,
+		DeleteRetention:    Duration(30 * 24 * time.Hour),
+	}
+}
+
+// defaultConfigPath is read when PLACIFY_CONFIG is not set. Unlike a file
+// named by PLACIFY_CONFIG, it may be missing.
+const defaultConfigPath = "placify.json"
+
+// configEnv lists the environment variables that override the config file
+var configEnv = []struct {
+	name string
+	set  func(c *Config, v string) error
+}{
+	{"PLACIFY_LISTEN", func(c *Config, v string) error { c.Listen = v; return nil }},
+	{"PLACIFY_ALLOWED_ORIGINS", func(c *Config, v string) error {
+		c.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				c.AllowedOrigins = append(c.AllowedOrigins, origin)
+			}
+		}
+		return nil
+	}},
+	{"PLACIFY_DB_DRIVER", func(c *Config, v string) error { c.Database.Driver = v; return nil }},
+	{"PLACIFY_DB_PATH", func(c *Config, v string) error { c.Database.Path = v; return nil }},
+	{"PLACIFY_DATABASE_URL", func(c *Config, v string) error { c.Database.URL = v; return nil }},
+	{"PLACIFY_DB_HOST", func(c *Config, v string) error { c.Database.Host = v; return nil }},
+	{"PLACIFY_DB_PORT", func(c *Config, v string) error { return setInt(&c.Database.Port, v) }},
+	{"PLACIFY_DB_USER", func(c *Config, v string) error { c.Database.User = v; return nil }},
+	{"PLACIFY_DB_PASSWORD", func(c *Config, v string) error { c.Database.Password = v; return nil }},
+	{"PLACIFY_DB_NAME", func(c *Config, v string) error { c.Database.Name = v; return nil }},
+	{"PLACIFY_DB_SSLMODE", func(c *Config, v string) error { c.Database.SSLMode = v; return nil }},
+	{"PLACIFY_DB_TIMEZONE", func(c *Config, v string) error { c.Database.TimeZone = v; return nil }},
+	{"PLACIFY_SESSION_SECRET", func(c *Config, v string) error { c.SessionSecret = v; return nil }},
+	{"GITHUB_TOKEN", func(c *Config, v string) error { c.GithubToken = v; return nil }},
+	{"PLACIFY_LEETCODE_PROVIDER", func(c *Config, v string) error { c.LeetCode.Provider = v; return nil }},
+	{"PLACIFY_LEETCODE_PROXY_URL", func(c *Config, v string) error { c.LeetCode.ProxyURL = v; return nil }},
+	{"PLACIFY_LEETCODE_FIXTURES", func(c *Config, v string) error { c.LeetCode.Fixtures = v; return nil }},
+	{"PLACIFY_CHAT_PROVIDER", func(c *Config, v string) error { c.Chat.Provider = v; return nil }},
+	{"PLACIFY_CHAT_URL", func(c *Config, v string) error { c.Chat.URL = v; return nil }},
+	{"PLACIFY_CHAT_MODEL", func(c *Config, v string) error { c.Chat.Model = v; return nil }},
+	{"PLACIFY_CHAT_API_KEY", func(c *Config, v string) error { c.Chat.APIKey = v; return nil }},
+	{"PLACIFY_RESUME_DIR", func(c *Config, v string) error { c.ResumeDir = v; return nil }},
+	{"PLACIFY_PUBLIC_KEY", func(c *Config, v string) error { c.PublicKeyPath = v; return nil }},
+	{"PLACIFY_SCORE_WEIGHTS", func(c *Config, v string) error { c.ScoreWeightsPath = v; return nil }},
+	{"PLACIFY_SKILLS", func(c *Config, v string) error { c.SkillsPath = v; return nil }},
+	{"PLACIFY_REFRESH_INTERVAL", func(c *Config, v string) error { return setDuration(&c.RefreshInterval, v) }},
+	{"PLACIFY_REFRESH_CONCURRENCY", func(c *Config, v string) error { return setInt(&c.RefreshConcurrency, v) }},
+	{"PLACIFY_DELETE_RETENTION", func(c *Config, v string) error { return setDuration(&c.DeleteRetention, v) }},
+}
+
+func setInt(dst *int, v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return errors.New("must be an integer")
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *Duration, v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return errors.New("must be a duration such as \"6h\"")
+	}
+	*dst = Duration(d)
+	return nil
+}
+
+// loadConfig reads the config file named by PLACIFY_CONFIG, or placify.json
+// if it exists, applies the environment on top of the defaults and the file,
+// and checks the result. getenv is os.Getenv outside of tests.
+func loadConfig(getenv func(string) string) (Config, error) {
+	cfg := defaultConfig()
+	path, explicit := getenv("PLACIFY_CONFIG"), true
+	if path == "" {
+		path, explicit = defaultConfigPath, false
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	case err != nil:
+		return cfg, fmt.Errorf("could not read config: %v", err)
+	default:
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("could not parse %s: %v", path, err)
+		}
+	}
+
+	var problems []string
+	for _, env := range configEnv {
+		if v := getenv(env.name); v != "" {
+			if err := env.set(&cfg, v); err != nil {
+				problems = append(problems, fmt.Sprintf("%s %v", env.name, err))
+			}
+		}
+	}
+	problems = append(problems, cfg.validate()...)
+	if len(problems) > 0 {
+		return cfg, fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return cfg, nil
+}
+
+// validate lists everything wrong with the config
+func (c Config) validate() []string {
+	var problems []string
+	if _, port, err := net.SplitHostPort(c.Listen); err != nil || port == "" {
+		problems = append(problems, fmt.Sprintf("listen %q must be host:port or :port", c.Listen))
+	}
+	switch c.Database.Driver {
+	case "postgres":
+	case "sqlite":
+		if c.Database.Path == "" {
+			problems = append(problems, "database path is required for the sqlite driver")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown database driver %q", c.Database.Driver))
+	}
+	if c.Database.Driver == "postgres" && c.Database.URL == "" {
+		db := c.Database
+		if db.Host == "" || db.User == "" || db.Name == "" {
+			problems = append(problems, "database host, user and name are required unless database url is set")
+		}
+		if db.Port < 1 || db.Port > 65535 {
+			problems = append(problems, fmt.Sprintf("database port %d is out of range", db.Port))
+		}
+		switch db.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			problems = append(problems, fmt.Sprintf("database sslmode %q is not a postgres sslmode", db.SSLMode))
+		}
+		if _, err := time.LoadLocation(db.TimeZone); err != nil {
+			problems = append(problems, fmt.Sprintf("database timezone %q is unknown", db.TimeZone))
+		}
+	}
+	if len(c.AllowedOrigins) == 0 {
+		problems = append(problems, "allowed_origins must not be empty")
+	}
+	switch c.LeetCode.Provider {
+	case "graphql", "fixture":
+	case "proxy":
+		if c.LeetCode.ProxyURL == "" {
+			problems = append(problems, "leetcode proxy_url is required for the proxy provider")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown leetcode provider %q", c.LeetCode.Provider))
+	}
+	switch c.Chat.Provider {
+	case "rules":
+	case "openai":
+		if c.Chat.URL == "" || c.Chat.Model == "" {
+			problems = append(problems, "chat url and model are required for the openai provider")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown chat provider %q", c.Chat.Provider))
+	}
+	if c.ResumeDir == "" {
+		problems = append(problems, "resume_dir is required")
+	}
+	if c.RefreshInterval < 0 {
+		problems = append(problems, "refresh_interval must not be negative")
+	}
+	if c.RefreshConcurrency < 1 {
+		problems = append(problems, "refresh_concurrency must be at least 1")
+	}
+	if c.DeleteRetention <= 0 {
+		problems = append(problems, "delete_retention must be positive")
+	}
+	return problems
+}
+
+// refreshConfig is the part of the config the refresh scheduler needs
+func (c Config) refreshConfig() RefreshConfig {
+	return RefreshConfig{Interval: time.Duration(c.RefreshInterval), Concurrency: c.RefreshConcurrency}
+}
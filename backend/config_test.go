@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envMap(env map[string]string) func(string) string {
+	return func(name string) string { return env[name] }
+}
+
+// inTempDir runs the test in an empty directory, so no placify.json is found
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestLoadConfigFileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "placify.json")
+	err := os.WriteFile(path, []byte(`{
+		"listen": "127.0.0.1:9000",
+		"database": {"host": "db", "password": "it's secret", "timezone": "Asia/Kolkata"},
+		"refresh_interval": "1h"
+	}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(envMap(map[string]string{
+		"PLACIFY_CONFIG":           path,
+		"PLACIFY_DB_NAME":          "placify",
+		"PLACIFY_DELETE_RETENTION": "48h",
+	}))
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.Listen != "127.0.0.1:9000" || time.Duration(cfg.RefreshInterval) != time.Hour {
+		t.Errorf("file settings not applied: %+v", cfg)
+	}
+	if time.Duration(cfg.DeleteRetention) != 48*time.Hour || cfg.RefreshConcurrency != 4 {
+		t.Errorf("env or defaults not applied: %+v", cfg)
+	}
+	want := `host=db port=5432 user=postgres dbname=placify sslmode=disable TimeZone=Asia/Kolkata password='it\'s secret'`
+	if dsn := cfg.Database.DSN(); dsn != want {
+		t.Errorf("DSN = %s, want %s", dsn, want)
+	}
+}
+
+func TestLoadConfigDefaultsWithoutFile(t *testing.T) {
+	inTempDir(t)
+	cfg, err := loadConfig(envMap(nil))
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if cfg.Listen != ":8000" || cfg.ResumeDir != "resumes" || cfg.LeetCode.Provider != "graphql" {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestLoadConfigRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"PLACIFY_CONFIG": "missing.json"}, "could not read config"},
+		{map[string]string{"PLACIFY_LISTEN": "8000"}, "listen"},
+		{map[string]string{"PLACIFY_DB_PORT": "many"}, "PLACIFY_DB_PORT must be an integer"},
+		{map[string]string{"PLACIFY_DB_SSLMODE": "sometimes"}, "sslmode"},
+		{map[string]string{"PLACIFY_DB_TIMEZONE": "Mars/Olympus"}, "timezone"},
+		{map[string]string{"PLACIFY_DB_DRIVER": "mysql"}, "unknown database driver"},
+		{map[string]string{"PLACIFY_LEETCODE_PROVIDER": "proxy"}, "proxy_url is required"},
+		{map[string]string{"PLACIFY_CHAT_PROVIDER": "openai"}, "chat url and model are required"},
+		{map[string]string{"PLACIFY_REFRESH_CONCURRENCY": "0"}, "refresh_concurrency"},
+		{map[string]string{"PLACIFY_DELETE_RETENTION": "soon"}, "PLACIFY_DELETE_RETENTION must be a duration"},
+	}
+	inTempDir(t)
+	for _, tt := range tests {
+		_, err := loadConfig(envMap(tt.env))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loadConfig(%v) = %v, want an error about %q", tt.env, err, tt.want)
+		}
+	}
+}
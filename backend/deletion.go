@@ -0,0 +1,589 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Deleting a student moves all of their rows into deleted_students as one
+// archive and removes them from the live tables, so no other query has to
+// know about deleted students. The archive can be restored until it is
+// purged after the retention window.
+
+// deletionRetention is how long a deleted student can be restored, set from
+// the config
+var deletionRetention = 30 * 24 * time.Hour
+
+// DeletedStudent is the archive of a deleted student
+type DeletedStudent struct {
+	StudentID       string    `gorm:"primaryKey;column:student_id" json:"srn"`
+	Name            string    `gorm:"column:name" json:"name"`
+	MentorID        int       `gorm:"column:mentor_id" json:"mentor_id"`
+	DeletedAt       time.Time `gorm:"column:deleted_at" json:"deleted_at"`
+	DeletedBy       string    `gorm:"column:deleted_by" json:"deleted_by"`
+	RestorableUntil time.Time `gorm:"column:restorable_until" json:"restorable_until"`
+	Data            []byte    `gorm:"column:data" json:"-"`
+}
+
+func (DeletedStudent) TableName() string {
+	return "deleted_students"
+}
+
+// StudentAudit is one entry of the audit trail of student deletions
+type StudentAudit struct {
+	AuditID   int64     `gorm:"primaryKey;column:audit_id" json:"audit_id"`
+	StudentID string    `gorm:"column:student_id" json:"srn"`
+	Action    string    `gorm:"column:action" json:"action"`
+	Actor     string    `gorm:"column:actor" json:"actor"`
+	At        time.Time `gorm:"column:at" json:"at"`
+	Detail    string    `gorm:"column:detail" json:"detail,omitempty"`
+}
+
+func (StudentAudit) TableName() string {
+	return "student_audit"
+}
+
+const (
+	auditDelete  = "delete"
+	auditRestore = "restore"
+	auditPurge   = "purge"
+)
+
+func recordAudit(db *gorm.DB, srn, action, actor, detail string) error {
+	err := db.Exec(`INSERT INTO student_audit (student_id, action, actor, at, detail)
+		VALUES ($1, $2, $3, $4, $5)`, srn, action, actor, time.Now(), detail).Error
+	if err != nil {
+		return fmt.Errorf("could not record audit entry: %v", err)
+	}
+	return nil
+}
+
+// studentRecords is every row that belongs to one student. Refresh status and
+// the resume index are left out, they are rebuilt after a restore. Chat history
+// is not kept.
+type studentRecords struct {
+	Student        Student
+	Credential     *StudentCredential
+	Github         []Github
+	Repositories   []Repository
+	RepoLanguages  []RepoLanguage
+	LeetCode       []LeetCode
+	Problems       []Problems
+	Snapshots      []LeetCodeSnapshot
+	MentorSessions []MentorSessionJSON
+	ResumeVersions []ResumeVersion
+	Applications   []Application
+	Events         []ApplicationEvent
+}
+
+// collectStudentRecords loads all rows of a student. found is false when
+// there is no such student.
+func collectStudentRecords(db *gorm.DB, srn string) (rec studentRecords, found bool, err error) {
+	res := db.Raw("SELECT * FROM student WHERE student_id = ?", srn).Scan(&rec.Student)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return rec, false, res.Error
+	}
+	var cred StudentCredential
+	res = db.Raw("SELECT student_id, password_hash, updated_at FROM student_credentials WHERE student_id = ?", srn).Scan(&cred)
+	if res.Error != nil {
+		return rec, true, res.Error
+	}
+	if res.RowsAffected > 0 {
+		rec.Credential = &cred
+	}
+	queries := []struct {
+		dest  interface{}
+		query string
+	}{
+		{&rec.Github, "SELECT * FROM github WHERE student_id = ?"},
+		{&rec.Repositories, `
+			SELECT r.* FROM repository r
+			JOIN github g ON g.github_id = r.github_id
+			WHERE g.student_id = ?`},
+		{&rec.RepoLanguages, `
+			SELECT rl.* FROM repo_language rl
+			JOIN repository r ON r.repo_id = rl.repo_id
+			JOIN github g ON g.github_id = r.github_id
+			WHERE g.student_id = ?`},
+		{&rec.LeetCode, "SELECT * FROM leetcode WHERE student_id = ?"},
+		{&rec.Problems, `
+			SELECT p.* FROM problems p
+			JOIN leetcode l ON l.leetcode_id = p.leetcode_id
+			WHERE l.student_id = ?`},
+		{&rec.Snapshots, "SELECT * FROM leetcode_snapshots WHERE student_id = ? ORDER BY taken_at"},
+		{&rec.MentorSessions, `
+			SELECT session_id, student_id AS srn, mentor_id, ` + dateText(db, "date") + ` AS date, advice
+			FROM mentor_sessions
+			WHERE student_id = ?
+			ORDER BY date`},
+		{&rec.ResumeVersions, "SELECT * FROM resume_version WHERE student_id = ? ORDER BY version"},
+		{&rec.Applications, "SELECT * FROM application WHERE student_id = ? ORDER BY application_id"},
+		{&rec.Events, `
+			SELECT e.* FROM application_event e
+			JOIN application a ON a.application_id = e.application_id
+			WHERE a.student_id = ?
+			ORDER BY e.event_id`},
+	}
+	for _, q := range queries {
+		if err := db.Raw(q.query, srn).Scan(q.dest).Error; err != nil {
+			return rec, true, err
+		}
+	}
+	return rec, true, nil
+}
+
+// deleteStudentRecords removes every row of a student, children first so it
+// does not depend on the schema cascading
+func deleteStudentRecords(db *gorm.DB, srn string) error {
+	statements := []string{
+		`DELETE FROM repo_language WHERE repo_id IN (
+			SELECT r.repo_id FROM repository r JOIN github g ON g.github_id = r.github_id WHERE g.student_id = ?)`,
+		"DELETE FROM repository WHERE github_id IN (SELECT github_id FROM github WHERE student_id = ?)",
+		"DELETE FROM github WHERE student_id = ?",
+		"DELETE FROM problems WHERE leetcode_id IN (SELECT leetcode_id FROM leetcode WHERE student_id = ?)",
+		"DELETE FROM leetcode WHERE student_id = ?",
+		"DELETE FROM leetcode_snapshots WHERE student_id = ?",
+		"DELETE FROM mentor_sessions WHERE student_id = ?",
+		"DELETE FROM refresh_status WHERE student_id = ?",
+		"DELETE FROM resume_skill WHERE student_id = ?",
+		"DELETE FROM resume_text WHERE student_id = ?",
+		"DELETE FROM resume_version WHERE student_id = ?",
+		"DELETE FROM application_event WHERE application_id IN (SELECT application_id FROM application WHERE student_id = ?)",
+		"DELETE FROM application WHERE student_id = ?",
+		"DELETE FROM chat_message WHERE student_id = ?",
+		"DELETE FROM student_credentials WHERE student_id = ?",
+		"DELETE FROM student WHERE student_id = ?",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt, srn).Error; err != nil {
+			return fmt.Errorf("could not delete student rows: %v", err)
+		}
+	}
+	return nil
+}
+
+// restoreStudentRecords inserts the archived rows again. Problem ids are
+// allocated afresh, since the old id may have been handed out meanwhile.
+func restoreStudentRecords(db *gorm.DB, rec studentRecords) error {
+	if err := insertStudent(db, rec.Student); err != nil {
+		return err
+	}
+	if c := rec.Credential; c != nil {
+		err := db.Exec(`INSERT INTO student_credentials (student_id, password_hash, updated_at)
+			VALUES ($1, $2, $3)`, c.StudentID, c.PasswordHash, c.UpdatedAt).Error
+		if err != nil {
+			return fmt.Errorf("could not restore credentials: %v", err)
+		}
+	}
+	for _, g := range rec.Github {
+		if err := insertGithub(db, g); err != nil {
+			return err
+		}
+	}
+	for _, repo := range rec.Repositories {
+		if err := insertRepository(db, repo); err != nil {
+			return err
+		}
+	}
+	for _, rl := range rec.RepoLanguages {
+		err := db.Exec(`INSERT INTO repo_language (repo_id, language, bytes, percentage)
+			VALUES ($1, $2, $3, $4)`, rl.RepoID, rl.Language, rl.Bytes, rl.Percentage).Error
+		if err != nil {
+			return fmt.Errorf("could not restore repo language: %v", err)
+		}
+	}
+	for _, l := range rec.LeetCode {
+		if err := insertLeetCode(db, l); err != nil {
+			return err
+		}
+	}
+	for _, p := range rec.Problems {
+		if err := insertProblems(db, p); err != nil {
+			return err
+		}
+	}
+	for _, s := range rec.Snapshots {
+		err := db.Exec(`
+			INSERT INTO leetcode_snapshots (student_id, taken_at, no_easy, no_medium, no_hard, total_solved, ranking)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			s.StudentID, s.TakenAt, s.Easy, s.Medium, s.Hard, s.Total, s.Ranking).Error
+		if err != nil {
+			return fmt.Errorf("could not restore leetcode snapshot: %v", err)
+		}
+	}
+	for _, v := range rec.ResumeVersions {
+		if err := insertResumeVersion(db, v); err != nil {
+			return err
+		}
+	}
+	// Applications to drives removed meanwhile are dropped
+	restored := map[int64]bool{}
+	for _, a := range rec.Applications {
+		exists, err := rowExists(db, "SELECT 1 FROM drive WHERE drive_id = ?", a.DriveID)
+		if err != nil {
+			return fmt.Errorf("could not restore application: %v", err)
+		}
+		if !exists {
+			continue
+		}
+		err = db.Exec(`
+			INSERT INTO application (application_id, drive_id, student_id, stage, package_lpa, applied_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			a.ApplicationID, a.DriveID, a.StudentID, a.Stage, a.PackageLPA, a.AppliedAt, a.UpdatedAt).Error
+		if err != nil {
+			return fmt.Errorf("could not restore application: %v", err)
+		}
+		restored[a.ApplicationID] = true
+	}
+	for _, e := range rec.Events {
+		if !restored[e.ApplicationID] {
+			continue
+		}
+		if err := insertApplicationEvent(db, e); err != nil {
+			return err
+		}
+	}
+	for _, s := range rec.MentorSessions {
+		date, err := time.Parse(sessionDateLayout, s.Date)
+		if err != nil {
+			return fmt.Errorf("could not restore mentor session: %v", err)
+		}
+		err = db.Exec(`INSERT INTO mentor_sessions (session_id, mentor_id, student_id, date, advice)
+			VALUES ($1, $2, $3, $4, $5)`, s.SessionID, s.MentorID, s.SRN, date, s.Advice).Error
+		if err != nil {
+			return fmt.Errorf("could not restore mentor session: %v", err)
+		}
+	}
+	return nil
+}
+
+var (
+	errStudentNotFound  = errors.New("student not found")
+	errNotRestorable    = errors.New("no restorable deletion for this student")
+	errStudentRecreated = errors.New("a student with this srn exists again")
+)
+
+// softDeleteStudent archives and removes a student in one transaction
+func softDeleteStudent(db *gorm.DB, srn, actor string) (DeletedStudent, error) {
+	var archive DeletedStudent
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rec, found, err := collectStudentRecords(tx, srn)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return errStudentNotFound
+		}
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(rec); err != nil {
+			return fmt.Errorf("could not encode archive: %v", err)
+		}
+		now := time.Now()
+		archive = DeletedStudent{
+			StudentID:       srn,
+			Name:            rec.Student.Name,
+			MentorID:        rec.Student.MentorID,
+			DeletedAt:       now,
+			DeletedBy:       actor,
+			RestorableUntil: now.Add(deletionRetention),
+			Data:            buf.Bytes(),
+		}
+		// A student deleted, restored and deleted again replaces the old archive.
+		if err := tx.Exec("DELETE FROM deleted_students WHERE student_id = ?", srn).Error; err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			INSERT INTO deleted_students (student_id, name, mentor_id, deleted_at, deleted_by, restorable_until, data)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			archive.StudentID, archive.Name, archive.MentorID, archive.DeletedAt, archive.DeletedBy,
+			archive.RestorableUntil, archive.Data).Error
+		if err != nil {
+			return fmt.Errorf("could not store archive: %v", err)
+		}
+		if err := deleteStudentRecords(tx, srn); err != nil {
+			return err
+		}
+		detail := fmt.Sprintf("%d repositories, %d leetcode snapshots, %d mentor sessions archived",
+			len(rec.Repositories), len(rec.Snapshots), len(rec.MentorSessions))
+		return recordAudit(tx, srn, auditDelete, actor, detail)
+	})
+	return archive, err
+}
+
+// restoreStudent puts an archived student back, as long as it has not expired
+func restoreStudent(db *gorm.DB, srn, actor string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var archive DeletedStudent
+		res := tx.Raw("SELECT * FROM deleted_students WHERE student_id = ? AND restorable_until > ?", srn, time.Now()).Scan(&archive)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errNotRestorable
+		}
+		exists, err := rowExists(tx, "SELECT 1 FROM student WHERE student_id = ?", srn)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errStudentRecreated
+		}
+		var rec studentRecords
+		if err := gob.NewDecoder(bytes.NewReader(archive.Data)).Decode(&rec); err != nil {
+			return fmt.Errorf("could not decode archive: %v", err)
+		}
+		if err := restoreStudentRecords(tx, rec); err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM deleted_students WHERE student_id = ?", srn).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, srn, auditRestore, actor, "")
+	})
+}
+
+// archivedResumeKeys lists the resume files an archive still refers to
+func archivedResumeKeys(rec studentRecords) []string {
+	var keys []string
+	seen := map[string]bool{"": true}
+	add := func(key string) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for _, v := range rec.ResumeVersions {
+		add(v.Key)
+	}
+	add(rec.Student.Resume)
+	return keys
+}
+
+// referencedResumeFiles is the set of resume files that live students or the
+// remaining archives still use. Imported students can share a file, and the
+// store only looks at the last element of a key, so files are compared by
+// that name.
+func referencedResumeFiles(db *gorm.DB) (map[string]bool, error) {
+	var keys []string
+	err := db.Raw(`
+		SELECT resume FROM student WHERE resume IS NOT NULL AND resume <> ''
+		UNION
+		SELECT storage_key FROM resume_version`).Scan(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	var archives []DeletedStudent
+	if err := db.Raw("SELECT student_id, data FROM deleted_students").Scan(&archives).Error; err != nil {
+		return nil, err
+	}
+	for _, archive := range archives {
+		var rec studentRecords
+		if err := gob.NewDecoder(bytes.NewReader(archive.Data)).Decode(&rec); err != nil {
+			return nil, fmt.Errorf("could not decode archive of %s: %v", archive.StudentID, err)
+		}
+		keys = append(keys, archivedResumeKeys(rec)...)
+	}
+	files := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		files[filepath.Base(key)] = true
+	}
+	return files, nil
+}
+
+// purgeDeletedStudents drops the archives whose retention window has passed,
+// together with the resume files nothing else uses. The files go after the
+// archive row is gone, so a failed purge never leaves a restorable student
+// without them.
+func purgeDeletedStudents(db *gorm.DB, now time.Time) (int, error) {
+	var expired []string
+	if err := db.Raw("SELECT student_id FROM deleted_students WHERE restorable_until <= ?", now).Scan(&expired).Error; err != nil {
+		return 0, err
+	}
+	for _, srn := range expired {
+		var keys []string
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var archive DeletedStudent
+			if err := tx.Raw("SELECT * FROM deleted_students WHERE student_id = ?", srn).Scan(&archive).Error; err != nil {
+				return err
+			}
+			var rec studentRecords
+			if err := gob.NewDecoder(bytes.NewReader(archive.Data)).Decode(&rec); err != nil {
+				return fmt.Errorf("could not decode archive of %s: %v", srn, err)
+			}
+			if err := tx.Exec("DELETE FROM deleted_students WHERE student_id = ?", srn).Error; err != nil {
+				return err
+			}
+			inUse, err := referencedResumeFiles(tx)
+			if err != nil {
+				return err
+			}
+			keys = nil
+			for _, key := range archivedResumeKeys(rec) {
+				if !inUse[filepath.Base(key)] {
+					keys = append(keys, key)
+				}
+			}
+			return recordAudit(tx, srn, auditPurge, "system", "retention window passed")
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, key := range keys {
+			if err := resumeStore.Delete(context.Background(), key); err != nil {
+				log.Printf("deletion: could not remove resume %s of %s: %v", key, srn, err)
+			}
+		}
+	}
+	return len(expired), nil
+}
+
+// startDeletionPurger purges expired archives once at startup and then hourly
+func startDeletionPurger(ctx context.Context, db *gorm.DB) {
+	purge := func() {
+		n, err := purgeDeletedStudents(db, time.Now())
+		if err != nil {
+			log.Printf("deletion: purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("deletion: purged %d expired students", n)
+		}
+	}
+	go func() {
+		purge()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purge()
+			}
+		}
+	}()
+}
+
+// auditActor names the account of the request for the audit trail
+func auditActor(r *http.Request) string {
+	s, _ := sessionFromContext(r.Context())
+	return s.Role + ":" + s.Subject
+}
+
+// DeleteStudent implements DELETE /students/{srn}, for the student themselves
+// or their mentor
+func DeleteStudent(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	archive, err := softDeleteStudent(db, srn, auditActor(r))
+	if errors.Is(err, errStudentNotFound) {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "student not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("failed to delete student %s: %v", srn, err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to delete student"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(archive)
+}
+
+// RestoreStudent implements POST /students/{srn}/restore for the mentor the
+// student had when they were deleted
+func RestoreStudent(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		writeAPIError(w, http.StatusForbidden, apiError{Error: "not allowed for this account"})
+		return
+	}
+	srn := strings.ToUpper(mux.Vars(r)["srn"])
+	owns, err := rowExists(db, "SELECT 1 FROM deleted_students WHERE student_id = ? AND mentor_id = ?", srn, mentorID)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	if !owns {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: errNotRestorable.Error()})
+		return
+	}
+	switch err := restoreStudent(db, srn, auditActor(r)); {
+	case errors.Is(err, errNotRestorable):
+		writeAPIError(w, http.StatusGone, apiError{Error: err.Error()})
+	case errors.Is(err, errStudentRecreated):
+		writeAPIError(w, http.StatusConflict, apiError{Error: err.Error()})
+	case err != nil:
+		log.Printf("failed to restore student %s: %v", srn, err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to restore student"})
+	default:
+		if err := indexStudentResume(r.Context(), db, srn); err != nil {
+			log.Printf("could not index resume of %s: %v", srn, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"srn": srn, "status": "restored"})
+	}
+}
+
+// ListDeletedStudents lists the mentor's deleted students that can still be
+// restored
+func ListDeletedStudents(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return
+	}
+	deleted := []DeletedStudent{}
+	err := db.Raw(`
+		SELECT student_id, name, mentor_id, deleted_at, deleted_by, restorable_until
+		FROM deleted_students
+		WHERE mentor_id = ? AND restorable_until > ?
+		ORDER BY deleted_at DESC`, mentorID, time.Now()).Scan(&deleted).Error
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deleted)
+}
+
+// GetStudentAudit returns the audit trail of a student of the mentor, including
+// students that are currently deleted
+func GetStudentAudit(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return
+	}
+	srn := strings.ToUpper(mux.Vars(r)["srn"])
+	owns, err := mentorOwnsStudent(db, mentorID, srn)
+	if err == nil && !owns {
+		owns, err = rowExists(db, "SELECT 1 FROM deleted_students WHERE student_id = ? AND mentor_id = ?", srn, mentorID)
+	}
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if !owns {
+		http.Error(w, "Student not found", http.StatusNotFound)
+		return
+	}
+	entries := []StudentAudit{}
+	if err := db.Raw("SELECT * FROM student_audit WHERE student_id = ? ORDER BY at", srn).Scan(&entries).Error; err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// eligibilityInputs is what the eligibility rules of a drive look at
+type eligibilityInputs struct {
+	SRN            string   `json:"srn"`
+	Name           string   `json:"name"`
+	CGPA           float64  `json:"cgpa"`
+	Sem            int      `json:"sem"`
+	Degree         string   `json:"degree"`
+	Stream         string   `json:"stream"`
+	LeetCodeSolved int      `json:"leetcode_solved"`
+	Languages      []string `gorm:"-" json:"languages"`
+}
+
+// containsFold reports whether list holds s, ignoring case and surrounding space
+func containsFold(list []string, s string) bool {
+	s = strings.TrimSpace(s)
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// unmetRules lists the rules a student fails. An empty list means eligible.
+func unmetRules(rules EligibilityRules, in eligibilityInputs) []string {
+	var unmet []string
+	if rules.MinCGPA != nil && in.CGPA < *rules.MinCGPA {
+		unmet = append(unmet, fmt.Sprintf("cgpa %.2f is below %.2f", in.CGPA, *rules.MinCGPA))
+	}
+	if len(rules.Degrees) > 0 && !containsFold(rules.Degrees, in.Degree) {
+		unmet = append(unmet, fmt.Sprintf("degree %q is not one of %s", in.Degree, strings.Join(rules.Degrees, ", ")))
+	}
+	if len(rules.Streams) > 0 && !containsFold(rules.Streams, in.Stream) {
+		unmet = append(unmet, fmt.Sprintf("stream %q is not one of %s", in.Stream, strings.Join(rules.Streams, ", ")))
+	}
+	if rules.MinSem != nil && in.Sem < *rules.MinSem {
+		unmet = append(unmet, fmt.Sprintf("semester %d is below %d", in.Sem, *rules.MinSem))
+	}
+	if rules.MaxSem != nil && in.Sem > *rules.MaxSem {
+		unmet = append(unmet, fmt.Sprintf("semester %d is above %d", in.Sem, *rules.MaxSem))
+	}
+	if rules.MinLeetCodeSolved != nil && in.LeetCodeSolved < *rules.MinLeetCodeSolved {
+		unmet = append(unmet, fmt.Sprintf("%d LeetCode problems solved, %d needed", in.LeetCodeSolved, *rules.MinLeetCodeSolved))
+	}
+	for _, lang := range rules.RequiredLanguages {
+		if !containsFold(in.Languages, lang) {
+			unmet = append(unmet, fmt.Sprintf("no repository in %s", lang))
+		}
+	}
+	return unmet
+}
+
+// fetchEligibilityInputs loads the eligibility inputs of one student, or of
+// every student when srn is empty
+func fetchEligibilityInputs(db *gorm.DB, srn string) ([]eligibilityInputs, error) {
+	where, githubWhere, args := "", "", []interface{}{}
+	if srn != "" {
+		where, githubWhere, args = "WHERE s.student_id = ?", "WHERE g.student_id = ?", append(args, srn)
+	}
+	var students []eligibilityInputs
+	err := db.Raw(`
+		SELECT s.student_id AS srn, s.name, s.cgpa, s.sem, s.degree, s.stream,
+			COALESCE(SUM(p.no_easy + p.no_medium + p.no_hard), 0) AS leetcode_solved
+		FROM student s
+		LEFT JOIN leetcode l ON l.student_id = s.student_id
+		LEFT JOIN problems p ON p.leetcode_id = l.leetcode_id
+		`+where+`
+		GROUP BY s.student_id, s.name, s.cgpa, s.sem, s.degree, s.stream
+		ORDER BY s.student_id`, args...).Scan(&students).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Languages come from the per-repository breakdown, and from the
+	// repository's language list for repositories imported before it existed
+	var langs []struct {
+		SRN      string
+		Language string
+	}
+	err = db.Raw(`
+		SELECT g.student_id AS srn, rl.language
+		FROM github g
+		JOIN repository r ON r.github_id = g.github_id
+		JOIN repo_language rl ON rl.repo_id = r.repo_id
+		`+githubWhere+`
+		UNION
+		SELECT g.student_id AS srn, r.language
+		FROM github g
+		JOIN repository r ON r.github_id = g.github_id
+		`+githubWhere, append(args, args...)...).Scan(&langs).Error
+	if err != nil {
+		return nil, err
+	}
+	bySRN := map[string][]string{}
+	for _, l := range langs {
+		for _, lang := range strings.Split(l.Language, ",") {
+			if lang = strings.TrimSpace(lang); lang != "" && !containsFold(bySRN[l.SRN], lang) {
+				bySRN[l.SRN] = append(bySRN[l.SRN], lang)
+			}
+		}
+	}
+	for i := range students {
+		students[i].Languages = bySRN[students[i].SRN]
+		if students[i].Languages == nil {
+			students[i].Languages = []string{}
+		}
+		sort.Strings(students[i].Languages)
+	}
+	return students, nil
+}
+
+// studentEligibility is a student checked against a drive
+type studentEligibility struct {
+	eligibilityInputs
+	Eligible bool     `json:"eligible"`
+	Unmet    []string `json:"unmet,omitempty"`
+}
+
+// ListEligibleStudents implements GET /drives/{drive_id}/eligible. With
+// ?all=true the ineligible students are listed too, with the rules they fail.
+func ListEligibleStudents(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	d, ok := driveFromURL(db, w, r)
+	if !ok {
+		return
+	}
+	all := r.URL.Query().Get("all") == "true"
+	students, err := fetchEligibilityInputs(db, "")
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	result := []studentEligibility{}
+	eligible := 0
+	for _, s := range students {
+		unmet := unmetRules(d.Eligibility, s)
+		if len(unmet) == 0 {
+			eligible++
+		} else if !all {
+			continue
+		}
+		result = append(result, studentEligibility{eligibilityInputs: s, Eligible: len(unmet) == 0, Unmet: unmet})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"drive":    d,
+		"eligible": eligible,
+		"checked":  len(students),
+		"students": result,
+	})
+}
+
+// GetDriveEligibility implements GET /drives/{drive_id}/eligibility?srn=,
+// letting a student see whether they may apply and why not
+func GetDriveEligibility(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	d, ok := driveFromURL(db, w, r)
+	if !ok {
+		return
+	}
+	srn := r.URL.Query().Get("srn")
+	students, err := fetchEligibilityInputs(db, srn)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	if len(students) == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "student not found"})
+		return
+	}
+	unmet := unmetRules(d.Eligibility, students[0])
+	writeJSON(w, http.StatusOK, studentEligibility{eligibilityInputs: students[0], Eligible: len(unmet) == 0, Unmet: unmet})
+}
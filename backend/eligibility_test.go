@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnmetRules(t *testing.T) {
+	minCGPA, minSem, maxSem, minSolved := 8.0, 5, 7, 100
+	rules := EligibilityRules{
+		MinCGPA:           &minCGPA,
+		Degrees:           []string{"B.Tech", "BE"},
+		Streams:           []string{"Computer Science"},
+		MinSem:            &minSem,
+		MaxSem:            &maxSem,
+		MinLeetCodeSolved: &minSolved,
+		RequiredLanguages: []string{"Go", "Python"},
+	}
+	eligible := eligibilityInputs{
+		CGPA: 8, Sem: 5, Degree: " b.tech ", Stream: "computer science",
+		LeetCodeSolved: 100, Languages: []string{"go", "Python", "C"},
+	}
+	tests := []struct {
+		name   string
+		change func(in *eligibilityInputs)
+		want   []string
+	}{
+		{"eligible", func(in *eligibilityInputs) {}, nil},
+		{"cgpa", func(in *eligibilityInputs) { in.CGPA = 7.99 }, []string{"cgpa 7.99 is below 8.00"}},
+		{"degree", func(in *eligibilityInputs) { in.Degree = "MCA" }, []string{`degree "MCA" is not one of B.Tech, BE`}},
+		{"stream", func(in *eligibilityInputs) { in.Stream = "Mechanical" }, []string{`stream "Mechanical" is not one of Computer Science`}},
+		{"semester too low", func(in *eligibilityInputs) { in.Sem = 4 }, []string{"semester 4 is below 5"}},
+		{"semester too high", func(in *eligibilityInputs) { in.Sem = 8 }, []string{"semester 8 is above 7"}},
+		{"leetcode", func(in *eligibilityInputs) { in.LeetCodeSolved = 99 }, []string{"99 LeetCode problems solved, 100 needed"}},
+		{"languages", func(in *eligibilityInputs) { in.Languages = []string{"C"} }, []string{"no repository in Go", "no repository in Python"}},
+	}
+	for _, tt := range tests {
+		in := eligible
+		tt.change(&in)
+		if got := unmetRules(rules, in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: unmetRules = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := unmetRules(EligibilityRules{}, eligibilityInputs{}); got != nil {
+		t.Errorf("a drive without rules rejects a student: %q", got)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// GithubClient fetches a GitHub profile together with its pinned repositories
+type GithubClient interface {
+	FetchProfile(ctx context.Context, username string) (ProfileData, error)
+}
+
+// githubClient is used by onboarding, the importer and the refresh scheduler
+var githubClient GithubClient = newGithubAPIClient("")
+
+const maxPinnedRepos = 6
+
+// githubAPIClient talks to the official GitHub REST and GraphQL APIs. Pinned
+// repositories are only exposed through GraphQL, which needs a token; without
+// one the most starred public repositories are used instead.
+type githubAPIClient struct {
+	httpClient *http.Client
+	restURL    string
+	graphqlURL string
+	token      string
+}
+
+func newGithubAPIClient(token string) *githubAPIClient {
+	return &githubAPIClient{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		restURL:    "https://api.github.com",
+		graphqlURL: "https://api.github.com/graphql",
+		token:      token,
+	}
+}
+
+// githubAPIError is a non-2xx response from the GitHub API
+type githubAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *githubAPIError) Error() string {
+	return fmt.Sprintf("github api: status %d: %s", e.StatusCode, e.Message)
+}
+
+func (c *githubAPIClient) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("github api: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("github api: reading response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+		return &githubAPIError{StatusCode: resp.StatusCode, Message: apiErr.Message}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("github api: parsing response: %v", err)
+	}
+	return nil
+}
+
+func (c *githubAPIClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.restURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, out)
+}
+
+const pinnedItemsQuery = `query($login: String!) {
+  user(login: $login) {
+    login
+    bio
+    repositories(ownerAffiliations: OWNER, privacy: PUBLIC) { totalCount }
+    pinnedItems(first: 6, types: REPOSITORY) {
+      nodes {
+        ... on Repository {
+          name
+          description
+          stargazerCount
+          owner { login }
+        }
+      }
+    }
+  }
+}`
+
+type pinnedRepoNode struct {
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	StargazerCount int    `json:"stargazerCount"`
+	Owner          struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+func (c *githubAPIClient) graphql(ctx context.Context, username string) (ProfileData, []pinnedRepoNode, error) {
+	var profile ProfileData
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":     pinnedItemsQuery,
+		"variables": map[string]string{"login": username},
+	})
+	if err != nil {
+		return profile, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.graphqlURL, bytes.NewReader(payload))
+	if err != nil {
+		return profile, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var result struct {
+		Data struct {
+			User *struct {
+				Login        string `json:"login"`
+				Bio          string `json:"bio"`
+				Repositories struct {
+					TotalCount int `json:"totalCount"`
+				} `json:"repositories"`
+				PinnedItems struct {
+					Nodes []pinnedRepoNode `json:"nodes"`
+				} `json:"pinnedItems"`
+			} `json:"user"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return profile, nil, err
+	}
+	if len(result.Errors) > 0 {
+		return profile, nil, fmt.Errorf("github graphql: %s", result.Errors[0].Message)
+	}
+	user := result.Data.User
+	if user == nil {
+		return profile, nil, &githubAPIError{StatusCode: http.StatusNotFound, Message: "user not found"}
+	}
+	profile.Username = user.Login
+	profile.Bio = user.Bio
+	profile.RepoCount = strconv.Itoa(user.Repositories.TotalCount)
+	return profile, user.PinnedItems.Nodes, nil
+}
+
+// rest is the token-less fallback, it cannot see pinned repositories
+func (c *githubAPIClient) rest(ctx context.Context, username string) (ProfileData, []pinnedRepoNode, error) {
+	var profile ProfileData
+	var user struct {
+		Login       string `json:"login"`
+		Bio         string `json:"bio"`
+		PublicRepos int    `json:"public_repos"`
+	}
+	if err := c.get(ctx, "/users/"+url.PathEscape(username), &user); err != nil {
+		return profile, nil, err
+	}
+	profile.Username = user.Login
+	profile.Bio = user.Bio
+	profile.RepoCount = strconv.Itoa(user.PublicRepos)
+
+	var repos []struct {
+		Name            string `json:"name"`
+		Description     string `json:"description"`
+		StargazersCount int    `json:"stargazers_count"`
+		Fork            bool   `json:"fork"`
+		Owner           struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	}
+	if err := c.get(ctx, "/users/"+url.PathEscape(username)+"/repos?type=owner&per_page=100", &repos); err != nil {
+		return profile, nil, err
+	}
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].StargazersCount > repos[j].StargazersCount })
+
+	var nodes []pinnedRepoNode
+	for _, repo := range repos {
+		if repo.Fork {
+			continue
+		}
+		node := pinnedRepoNode{Name: repo.Name, Description: repo.Description, StargazerCount: repo.StargazersCount}
+		node.Owner.Login = repo.Owner.Login
+		nodes = append(nodes, node)
+		if len(nodes) == maxPinnedRepos {
+			break
+		}
+	}
+	return profile, nodes, nil
+}
+
+// languages returns the bytes of code per language of a repository
+func (c *githubAPIClient) languages(ctx context.Context, owner, repo string) (map[string]int, error) {
+	languages := map[string]int{}
+	err := c.get(ctx, "/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/languages", &languages)
+	return languages, err
+}
+
+func (c *githubAPIClient) FetchProfile(ctx context.Context, username string) (ProfileData, error) {
+	fetch := c.rest
+	if c.token != "" {
+		fetch = c.graphql
+	}
+	profile, nodes, err := fetch(ctx, username)
+	if err != nil {
+		return profile, err
+	}
+	for _, node := range nodes {
+		owner := node.Owner.Login
+		if owner == "" {
+			owner = profile.Username
+		}
+		langBytes, err := c.languages(ctx, owner, node.Name)
+		if err != nil {
+			return profile, fmt.Errorf("languages of %s/%s: %v", owner, node.Name, err)
+		}
+		profile.PinnedRepos = append(profile.PinnedRepos, PinnedRepo{
+			Name:          node.Name,
+			About:         node.Description,
+			Stars:         node.StargazerCount,
+			Languages:     languagesBySize(langBytes),
+			LanguageBytes: langBytes,
+		})
+	}
+	return profile, nil
+}
+
+// languagesBySize orders the language names by bytes of code, largest first
+func languagesBySize(langBytes map[string]int) []string {
+	languages := make([]string, 0, len(langBytes))
+	for lang := range langBytes {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if langBytes[languages[i]] != langBytes[languages[j]] {
+			return langBytes[languages[i]] > langBytes[languages[j]]
+		}
+		return languages[i] < languages[j]
+	})
+	return languages
+}
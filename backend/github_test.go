@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newFakeGithub serves just enough of the GitHub API for one user, "octo"
+func newFakeGithub(t *testing.T, wantToken string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /graphql", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+wantToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Variables["login"] != "octo" {
+			w.Write([]byte(`{"data":{"user":null},"errors":[{"message":"Could not resolve to a User"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"user":{
+			"login":"octo","bio":"hello",
+			"repositories":{"totalCount":12},
+			"pinnedItems":{"nodes":[
+				{"name":"placify","description":"placement portal","stargazerCount":7,"owner":{"login":"octo"}},
+				{"name":"forked","description":"","stargazerCount":1,"owner":{"login":"upstream"}}
+			]}}}}`))
+	})
+	mux.HandleFunc("GET /users/octo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"login":"octo","bio":"hello","public_repos":3}`))
+	})
+	mux.HandleFunc("GET /users/octo/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"name":"small","description":"","stargazers_count":1,"fork":false,"owner":{"login":"octo"}},
+			{"name":"placify","description":"placement portal","stargazers_count":7,"fork":false,"owner":{"login":"octo"}},
+			{"name":"copy","description":"","stargazers_count":99,"fork":true,"owner":{"login":"octo"}}
+		]`))
+	})
+	mux.HandleFunc("GET /repos/octo/placify/languages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"TypeScript":300,"Go":700}`))
+	})
+	mux.HandleFunc("GET /repos/upstream/forked/languages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"C":10}`))
+	})
+	mux.HandleFunc("GET /repos/octo/small/languages", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testGithubClient(srv *httptest.Server, token string) *githubAPIClient {
+	c := newGithubAPIClient(token)
+	c.httpClient = srv.Client()
+	c.restURL = srv.URL
+	c.graphqlURL = srv.URL + "/graphql"
+	return c
+}
+
+func TestGithubClientPinnedItems(t *testing.T) {
+	srv := newFakeGithub(t, "secret")
+	profile, err := testGithubClient(srv, "secret").FetchProfile(context.Background(), "octo")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	if profile.Username != "octo" || profile.Bio != "hello" || profile.RepoCount != "12" {
+		t.Errorf("profile = %+v", profile)
+	}
+	if len(profile.PinnedRepos) != 2 {
+		t.Fatalf("got %d pinned repos, want 2", len(profile.PinnedRepos))
+	}
+	repo := profile.PinnedRepos[0]
+	if repo.Name != "placify" || repo.About != "placement portal" || repo.Stars != 7 {
+		t.Errorf("pinned repo = %+v", repo)
+	}
+	if want := []string{"Go", "TypeScript"}; !reflect.DeepEqual(repo.Languages, want) {
+		t.Errorf("languages = %v, want %v", repo.Languages, want)
+	}
+	if repo.LanguageBytes["Go"] != 700 {
+		t.Errorf("language bytes = %v", repo.LanguageBytes)
+	}
+	if got := profile.PinnedRepos[1].Languages; !reflect.DeepEqual(got, []string{"C"}) {
+		t.Errorf("languages of a repo owned by someone else = %v", got)
+	}
+}
+
+func TestGithubClientWithoutToken(t *testing.T) {
+	srv := newFakeGithub(t, "secret")
+	profile, err := testGithubClient(srv, "").FetchProfile(context.Background(), "octo")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	var names []string
+	for _, repo := range profile.PinnedRepos {
+		names = append(names, repo.Name)
+	}
+	if want := []string{"placify", "small"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("repos = %v, want %v (most starred first, no forks)", names, want)
+	}
+	if profile.RepoCount != "3" {
+		t.Errorf("repo count = %q", profile.RepoCount)
+	}
+}
+
+func TestGithubClientErrors(t *testing.T) {
+	srv := newFakeGithub(t, "secret")
+
+	_, err := testGithubClient(srv, "wrong").FetchProfile(context.Background(), "octo")
+	var apiErr *githubAPIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad token: err = %v, want a 401 githubAPIError", err)
+	}
+
+	if _, err := testGithubClient(srv, "secret").FetchProfile(context.Background(), "ghost"); err == nil {
+		t.Error("unknown user: expected an error")
+	}
+	if _, err := testGithubClient(srv, "").FetchProfile(context.Background(), "ghost"); err == nil {
+		t.Error("unknown user without token: expected an error")
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestStore returns a store with two mentors: mentor 1 has PES1 and PES2,
+// mentor 2 has PES3. Every account's password is "password1".
+func newTestStore(t *testing.T) *memoryStore {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newMemoryStore()
+	s.mentors[1] = Mentor{MentorID: 1, Name: "Asha Rao"}
+	s.mentors[2] = Mentor{MentorID: 2, Name: "Vikram Iyer"}
+	s.mentorCreds[1] = MentorCredential{MentorID: 1, PasswordHash: string(hash)}
+	s.students["PES1"] = Student{StudentID: "PES1", Name: "Anu", Gender: "F", Sem: 5, MentorID: 1, CGPA: 9.1,
+		Email: "anu@example.com", Age: 20, Linkedin: "https://linkedin.com/in/anu", Degree: "BTech", Stream: "CSE"}
+	s.students["PES2"] = Student{StudentID: "PES2", Name: "Bala", Sem: 5, MentorID: 1, CGPA: 8.2}
+	s.students["PES3"] = Student{StudentID: "PES3", Name: "Chitra", Sem: 7, MentorID: 2, CGPA: 7.5}
+	s.studentCreds["PES1"] = StudentCredential{StudentID: "PES1", PasswordHash: string(hash)}
+
+	s.github["https://github.com/anu"] = Github{GithubID: "https://github.com/anu", StudentID: "PES1", Username: "anu"}
+	s.repositories["https://github.com/anu/placify"] = Repository{RepoID: "https://github.com/anu/placify",
+		GithubID: "https://github.com/anu", RepoName: "placify", Language: "Go", Desc: "placement portal"}
+	s.repositories["https://github.com/anu/dots"] = Repository{RepoID: "https://github.com/anu/dots",
+		GithubID: "https://github.com/anu", RepoName: "dots"}
+	s.languages = []RepoLanguage{
+		{RepoID: "https://github.com/anu/placify", Language: "TypeScript", Bytes: 300, Percentage: 30},
+		{RepoID: "https://github.com/anu/placify", Language: "Go", Bytes: 700, Percentage: 70},
+	}
+	s.leetcode["https://leetcode.com/anu"] = LeetCode{LeetCodeID: "https://leetcode.com/anu", StudentID: "PES1", Username: "anu", Rank: 1200}
+	s.problems["https://leetcode.com/anu"] = Problems{ProblemID: 1, LeetcodeID: "https://leetcode.com/anu", NoEasy: 50, NoMedium: 30, NoHard: 5}
+
+	for _, session := range []MentorSessionJSON{
+		{SRN: "PES1", MentorID: 1, Date: "2024-03-10", Advice: "Practice graphs"},
+		{SRN: "PES1", MentorID: 1, Date: "2024-01-05", Advice: "Update resume"},
+		{SRN: "PES3", MentorID: 2, Date: "2024-02-01", Advice: "Apply to drives"},
+	} {
+		s.CreateMentorSession(session)
+	}
+	return s
+}
+
+func newTestRouter(t *testing.T, store Store) http.Handler {
+	t.Helper()
+	initSessionSecret("handler-test-secret")
+	r := mux.NewRouter()
+	registerStoreRoutes(r, store)
+	return r
+}
+
+func testToken(t *testing.T, subject, role string) string {
+	t.Helper()
+	token, err := signSession(Session{Subject: subject, Role: role, Expires: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func serveRequest(h http.Handler, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeResponse(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("could not decode %q: %v", rec.Body.String(), err)
+	}
+}
+
+func TestLoginHandlers(t *testing.T) {
+	h := newTestRouter(t, newTestStore(t))
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{"POST", "/login", `{"srn":" pes1 ","password":"password1"}`, http.StatusOK},
+		{"POST", "/login", `{"srn":"PES1","password":"wrong"}`, http.StatusUnauthorized},
+		{"POST", "/login", `{"srn":"PES2","password":"password1"}`, http.StatusUnauthorized},
+		{"POST", "/login", `not json`, http.StatusBadRequest},
+		{"GET", "/mentorLogin?mentorId=1&password=password1", "", http.StatusMethodNotAllowed},
+		{"POST", "/mentorLogin", `{"mentorId":"1","password":"password1"}`, http.StatusOK},
+		{"POST", "/mentorLogin", `{"mentorId":"2","password":"password1"}`, http.StatusUnauthorized},
+		{"POST", "/mentorLogin", `{"mentorId":"abc","password":"password1"}`, http.StatusUnauthorized},
+		{"POST", "/mentorLogin", `not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := serveRequest(h, tt.method, tt.target, "", tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s %s = %d %s, want %d", tt.method, tt.target, tt.body, rec.Code, rec.Body, tt.want)
+		}
+	}
+
+	rec := serveRequest(h, "POST", "/login", "", `{"srn":"PES1","password":"password1"}`)
+	var login struct {
+		Token string `json:"token"`
+		SRN   string `json:"srn"`
+		Name  string `json:"name"`
+	}
+	decodeResponse(t, rec, &login)
+	if login.SRN != "PES1" || login.Name != "Anu" {
+		t.Errorf("login response = %+v", login)
+	}
+	if s, err := verifySession(login.Token); err != nil || s.Subject != "PES1" || s.Role != roleStudent {
+		t.Errorf("login token = %+v, %v", s, err)
+	}
+}
+
+func TestStudentHandlersCheckAccess(t *testing.T) {
+	h := newTestRouter(t, newTestStore(t))
+	tests := []struct {
+		target, token string
+		want          int
+	}{
+		{"/getInfo", "", http.StatusUnauthorized},
+		{"/getInfo", "not-a-token", http.StatusUnauthorized},
+		{"/getInfo", testToken(t, "1", roleMentor), http.StatusForbidden},
+		{"/getInfo?srn=PES2", testToken(t, "PES1", roleStudent), http.StatusForbidden},
+		{"/getInfo", testToken(t, "PES1", roleStudent), http.StatusOK},
+		{"/getInfo", testToken(t, "PES9", roleStudent), http.StatusNotFound},
+		{"/getLinkedin", testToken(t, "PES2", roleStudent), http.StatusNotFound},
+		{"/getGithub", testToken(t, "PES2", roleStudent), http.StatusNotFound},
+		{"/getLeetcode", testToken(t, "PES2", roleStudent), http.StatusNotFound},
+		{"/mentor/students", testToken(t, "PES1", roleStudent), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if rec := serveRequest(h, "GET", tt.target, tt.token, ""); rec.Code != tt.want {
+			t.Errorf("GET %s = %d %s, want %d", tt.target, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
+
+func TestStudentProfileHandlers(t *testing.T) {
+	h := newTestRouter(t, newTestStore(t))
+	token := testToken(t, "PES1", roleStudent)
+
+	if rec := serveRequest(h, "GET", "/student", token, ""); rec.Body.String() != "Anu" {
+		t.Errorf("/student = %q, want Anu", rec.Body)
+	}
+
+	var info map[string]interface{}
+	decodeResponse(t, serveRequest(h, "GET", "/getInfo", token, ""), &info)
+	if info["srn"] != "PES1" || info["cgpa"] != 9.1 || info["stream"] != "CSE" {
+		t.Errorf("/getInfo = %v", info)
+	}
+
+	var linkedin map[string]string
+	decodeResponse(t, serveRequest(h, "GET", "/getLinkedin", token, ""), &linkedin)
+	if linkedin["linkedin"] != "https://linkedin.com/in/anu" {
+		t.Errorf("/getLinkedin = %v", linkedin)
+	}
+
+	var github struct {
+		GithubID     string `json:"github_id"`
+		Repositories []struct {
+			RepoName  string         `json:"repo_name"`
+			Languages []RepoLanguage `json:"languages"`
+		} `json:"repositories"`
+	}
+	decodeResponse(t, serveRequest(h, "GET", "/getGithub", token, ""), &github)
+	if github.GithubID != "https://github.com/anu" || len(github.Repositories) != 2 {
+		t.Fatalf("/getGithub = %+v", github)
+	}
+	if dots := github.Repositories[0]; dots.RepoName != "dots" || len(dots.Languages) != 0 || dots.Languages == nil {
+		t.Errorf("repository without languages = %+v, want an empty list", dots)
+	}
+	var langs []string
+	for _, l := range github.Repositories[1].Languages {
+		langs = append(langs, l.Language)
+	}
+	if !reflect.DeepEqual(langs, []string{"Go", "TypeScript"}) {
+		t.Errorf("placify languages = %v, want largest first", langs)
+	}
+
+	var leetcode map[string]interface{}
+	decodeResponse(t, serveRequest(h, "GET", "/getLeetcode", token, ""), &leetcode)
+	if leetcode["ranking"] != 1200.0 || leetcode["hard_solved"] != 5.0 || leetcode["total_solved"] != 85.0 {
+		t.Errorf("/getLeetcode = %v", leetcode)
+	}
+
+	var sessions struct {
+		SRN      string `json:"srn"`
+		Sessions []struct {
+			Date string `json:"date"`
+		} `json:"sessions"`
+	}
+	decodeResponse(t, serveRequest(h, "GET", "/getMentorSessions", token, ""), &sessions)
+	if len(sessions.Sessions) != 2 || sessions.Sessions[0].Date != "2024-01-05" {
+		t.Errorf("/getMentorSessions = %+v, want two sessions oldest first", sessions)
+	}
+}
+
+func TestGetMentorStudents(t *testing.T) {
+	h := newTestRouter(t, newTestStore(t))
+	var resp struct {
+		MentorID int `json:"mentor_id"`
+		Students []struct {
+			SRN string `json:"srn"`
+		} `json:"students"`
+	}
+	decodeResponse(t, serveRequest(h, "GET", "/mentor/students", testToken(t, "1", roleMentor), ""), &resp)
+	if resp.MentorID != 1 || len(resp.Students) != 2 || resp.Students[0].SRN != "PES1" || resp.Students[1].SRN != "PES2" {
+		t.Errorf("/mentor/students = %+v", resp)
+	}
+}
+
+func TestMentorSessionHandlers(t *testing.T) {
+	store := newTestStore(t)
+	h := newTestRouter(t, store)
+	mentor := testToken(t, "1", roleMentor)
+	other := testToken(t, "2", roleMentor)
+
+	rec := serveRequest(h, "POST", "/mentor/sessions", mentor, `{"srn":"pes2","date":"2024-04-01","advice":"Mock interview"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", rec.Code, rec.Body)
+	}
+	var created MentorSessionJSON
+	decodeResponse(t, rec, &created)
+	if created.SessionID == 0 || created.SRN != "PES2" || created.MentorID != 1 {
+		t.Errorf("created session = %+v", created)
+	}
+
+	for _, tt := range []struct {
+		body string
+		want int
+	}{
+		{`{"srn":"PES3","date":"2024-04-01","advice":"x"}`, http.StatusForbidden},
+		{`{"srn":"PES2","date":"01-04-2024","advice":"x"}`, http.StatusBadRequest},
+		{`{"srn":"PES2","date":"2999-01-01","advice":"x"}`, http.StatusBadRequest},
+		{`{"srn":"PES2","date":"2024-04-01","advice":" "}`, http.StatusBadRequest},
+		{`{"date":"2024-04-01","advice":"x"}`, http.StatusBadRequest},
+	} {
+		if rec := serveRequest(h, "POST", "/mentor/sessions", mentor, tt.body); rec.Code != tt.want {
+			t.Errorf("create %s = %d %s, want %d", tt.body, rec.Code, rec.Body, tt.want)
+		}
+	}
+
+	var list struct {
+		Sessions []MentorSessionJSON `json:"sessions"`
+	}
+	decodeResponse(t, serveRequest(h, "GET", "/mentor/sessions", mentor, ""), &list)
+	if len(list.Sessions) != 3 || list.Sessions[0].Date != "2024-01-05" || list.Sessions[2].SessionID != created.SessionID {
+		t.Errorf("mentor sessions = %+v", list.Sessions)
+	}
+	decodeResponse(t, serveRequest(h, "GET", "/mentor/sessions?srn=PES2", mentor, ""), &list)
+	if len(list.Sessions) != 1 {
+		t.Errorf("sessions of PES2 = %+v", list.Sessions)
+	}
+
+	path := "/mentor/sessions/" + strconv.FormatInt(created.SessionID, 10)
+	if rec := serveRequest(h, "PUT", path, other, `{"advice":"mine now"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("update by another mentor = %d, want 404", rec.Code)
+	}
+	rec = serveRequest(h, "PUT", path, mentor, `{"advice":"Second mock interview"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body)
+	}
+	if stored, _, _ := store.MentorSession(created.SessionID); stored.Advice != "Second mock interview" || stored.Date != "2024-04-01" {
+		t.Errorf("updated session = %+v", stored)
+	}
+
+	if rec := serveRequest(h, "DELETE", path, mentor, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("delete = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serveRequest(h, "DELETE", path, mentor, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete = %d, want 404", rec.Code)
+	}
+	if rec := serveRequest(h, "DELETE", "/mentor/sessions/abc", mentor, ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("delete with a bad id = %d, want 400", rec.Code)
+	}
+}
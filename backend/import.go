@@ -0,0 +1,581 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jszwec/csvutil"
+	"gorm.io/gorm"
+)
+
+// importCounts tracks what happened to the rows of one table during an import
+type importCounts struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+// importOutcome is the result of upserting a single row
+type importOutcome int
+
+const (
+	outcomeCreated importOutcome = iota
+	outcomeUpdated
+	outcomeSkipped
+)
+
+// importReport collects the per-table counts of an import run
+type importReport struct {
+	tables []string
+	counts map[string]*importCounts
+}
+
+func newImportReport() *importReport {
+	return &importReport{counts: make(map[string]*importCounts)}
+}
+
+func (r *importReport) record(table string, outcome importOutcome) {
+	c, ok := r.counts[table]
+	if !ok {
+		c = &importCounts{}
+		r.counts[table] = c
+		r.tables = append(r.tables, table)
+	}
+	switch outcome {
+	case outcomeCreated:
+		c.Created++
+	case outcomeUpdated:
+		c.Updated++
+	default:
+		c.Skipped++
+	}
+}
+
+func (r *importReport) print(w io.Writer) {
+	for _, table := range r.tables {
+		c := r.counts[table]
+		fmt.Fprintf(w, "%-16s created: %d, updated: %d, skipped: %d\n", table, c.Created, c.Updated, c.Skipped)
+	}
+}
+
+// readInfoCSV decodes the student records from data.csv
+func readInfoCSV(path string) ([]Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open CSV file: %v", err)
+	}
+	defer file.Close()
+
+	decoder, err := csvutil.NewDecoder(csv.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("could not create CSV decoder: %v", err)
+	}
+
+	var all_info []Info
+	for {
+		var sing_info Info
+		if err := decoder.Decode(&sing_info); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("could not decode record: %v", err)
+		}
+		all_info = append(all_info, sing_info)
+	}
+	return all_info, nil
+}
+
+// readMentorSessionsCSV decodes the mentor session records from mentor_sesh.csv
+func readMentorSessionsCSV(path string) ([]Mentor_Session_CSV, error) {
+	mentor_file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mentor csv file: %v", err)
+	}
+	defer mentor_file.Close()
+
+	mentor_decoder, err := csvutil.NewDecoder(csv.NewReader(mentor_file))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't make mentor decoder: %v", err)
+	}
+
+	var mentor_info []Mentor_Session_CSV
+	for {
+		var men_info Mentor_Session_CSV
+		if err := mentor_decoder.Decode(&men_info); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("couldn't decode mentor record: %v", err)
+		}
+		mentor_info = append(mentor_info, men_info)
+	}
+	return mentor_info, nil
+}
+
+// rowExists reports whether query (a SELECT 1 ... statement) matches any row
+func rowExists(db *gorm.DB, query string, args ...interface{}) (bool, error) {
+	var found int
+	res := db.Raw(query, args...).Scan(&found)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// upsertStudent inserts the student or updates it when any column differs
+func upsertStudent(db *gorm.DB, student Student) (importOutcome, error) {
+	exists, err := rowExists(db, "SELECT 1 FROM student WHERE student_id = ?", student.StudentID)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if !exists {
+		return outcomeCreated, insertStudent(db, student)
+	}
+	res := db.Exec(`
+		UPDATE student
+		SET name = ?, phone_no = ?, dob = ?, gender = ?, resume = ?, sem = ?, mentor_id = ?, cgpa = ?,
+			email = ?, age = ?, linkedin = ?, degree = ?, stream = ?
+		WHERE student_id = ?
+		AND (name, phone_no, dob, gender, resume, sem, mentor_id, cgpa, email, age, linkedin, degree, stream)
+			IS DISTINCT FROM (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		student.Name, student.PhoneNo, nullableDate(student.Dob), student.Gender, student.Resume, student.Sem, student.MentorID, student.CGPA,
+		student.Email, student.Age, student.Linkedin, student.Degree, student.Stream,
+		student.StudentID,
+		student.Name, student.PhoneNo, nullableDate(student.Dob), student.Gender, student.Resume, student.Sem, student.MentorID, student.CGPA,
+		student.Email, student.Age, student.Linkedin, student.Degree, student.Stream)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("could not update student: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeUpdated, nil
+}
+
+// upsertGithub inserts the github row or refreshes its scraped fields
+func upsertGithub(db *gorm.DB, github Github) (importOutcome, error) {
+	exists, err := rowExists(db, "SELECT 1 FROM github WHERE github_id = ?", github.GithubID)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if !exists {
+		return outcomeCreated, insertGithub(db, github)
+	}
+	res := db.Exec(`
+		UPDATE github SET student_id = ?, username = ?, bio = ?, repo_count = ?
+		WHERE github_id = ?
+		AND (student_id, username, bio, repo_count) IS DISTINCT FROM (?, ?, ?, ?)`,
+		github.StudentID, github.Username, github.Bio, github.RepoCount,
+		github.GithubID,
+		github.StudentID, github.Username, github.Bio, github.RepoCount)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("could not update github: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeUpdated, nil
+}
+
+// upsertRepository inserts the pinned repository or refreshes its details
+func upsertRepository(db *gorm.DB, repo Repository) (importOutcome, error) {
+	exists, err := rowExists(db, "SELECT 1 FROM repository WHERE repo_id = ?", repo.RepoID)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if !exists {
+		return outcomeCreated, insertRepository(db, repo)
+	}
+	res := db.Exec(`
+		UPDATE repository SET github_id = ?, repo_name = ?, language = ?, description = ?
+		WHERE repo_id = ?
+		AND (github_id, repo_name, language, description) IS DISTINCT FROM (?, ?, ?, ?)`,
+		repo.GithubID, repo.RepoName, repo.Language, repo.Desc,
+		repo.RepoID,
+		repo.GithubID, repo.RepoName, repo.Language, repo.Desc)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("could not update repository: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeUpdated, nil
+}
+
+// upsertLeetCode inserts the leetcode row or refreshes its ranking
+func upsertLeetCode(db *gorm.DB, leetcode LeetCode) (importOutcome, error) {
+	exists, err := rowExists(db, "SELECT 1 FROM leetcode WHERE leetcode_id = ?", leetcode.LeetCodeID)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if !exists {
+		return outcomeCreated, insertLeetCode(db, leetcode)
+	}
+	res := db.Exec(`
+		UPDATE leetcode SET student_id = ?, username = ?, ranking = ?
+		WHERE leetcode_id = ?
+		AND (student_id, username, ranking) IS DISTINCT FROM (?, ?, ?)`,
+		leetcode.StudentID, leetcode.Username, leetcode.Rank,
+		leetcode.LeetCodeID,
+		leetcode.StudentID, leetcode.Username, leetcode.Rank)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("could not update leetcode: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeUpdated, nil
+}
+
+// upsertProblems keys the problems row on leetcode_id, since problem_id is
+// only a running counter and is not stable between runs
+func upsertProblems(db *gorm.DB, problems Problems) (importOutcome, error) {
+	exists, err := rowExists(db, "SELECT 1 FROM problems WHERE leetcode_id = ?", problems.LeetcodeID)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if !exists {
+		return outcomeCreated, insertProblems(db, problems)
+	}
+	res := db.Exec(`
+		UPDATE problems SET no_easy = ?, no_medium = ?, no_hard = ?
+		WHERE leetcode_id = ?
+		AND (no_easy, no_medium, no_hard) IS DISTINCT FROM (?, ?, ?)`,
+		problems.NoEasy, problems.NoMedium, problems.NoHard,
+		problems.LeetcodeID,
+		problems.NoEasy, problems.NoMedium, problems.NoHard)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("could not update problems: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeUpdated, nil
+}
+
+// upsertMentorSession treats a student's session on a given date as the same
+// session, so re-importing only changes the mentor or advice
+func upsertMentorSession(db *gorm.DB, session Mentor_Session_DB) (importOutcome, error) {
+	exists, err := rowExists(db, "SELECT 1 FROM mentor_sessions WHERE student_id = ? AND date = ?", session.StudentID, session.Date)
+	if err != nil {
+		return outcomeSkipped, err
+	}
+	if !exists {
+		return outcomeCreated, insertMentorSessions(db, session)
+	}
+	res := db.Exec(`
+		UPDATE mentor_sessions SET mentor_id = ?, advice = ?
+		WHERE student_id = ? AND date = ?
+		AND (mentor_id, advice) IS DISTINCT FROM (?, ?)`,
+		session.MentorID, session.Advice,
+		session.StudentID, session.Date,
+		session.MentorID, session.Advice)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("couldn't update mentor_sessions: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeUpdated, nil
+}
+
+// errFetchFailed marks errors from scraping a profile, as opposed to database errors
+var errFetchFailed = errors.New("could not fetch profile")
+
+// syncGithub fetches the student's GitHub profile and upserts the github row
+// and pinned repositories. Repositories that are no longer pinned are removed.
+func syncGithub(db *gorm.DB, srn, githubURL string, report *importReport) error {
+	username, err := getUsernameFromURL(githubURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFetchFailed, err)
+	}
+	profile, err := githubClient.FetchProfile(context.Background(), username)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFetchFailed, err)
+	}
+	return storeGithubProfile(db, srn, githubURL, profile, report)
+}
+
+// storeGithubProfile upserts an already fetched GitHub profile
+func storeGithubProfile(db *gorm.DB, srn, githubURL string, profile ProfileData, report *importReport) error {
+	outcome, err := upsertGithub(db, Github{
+		GithubID:  githubURL,
+		StudentID: srn,
+		Username:  profile.Username,
+		Bio:       profile.Bio,
+		RepoCount: profile.RepoCount,
+	})
+	if err != nil {
+		return err
+	}
+	report.record("github", outcome)
+
+	pinned := []string{}
+	for _, repo := range profile.PinnedRepos {
+		repoID := githubURL + "/" + repo.Name
+		pinned = append(pinned, repoID)
+		outcome, err := upsertRepository(db, Repository{
+			RepoID:   repoID,
+			GithubID: githubURL,
+			RepoName: repo.Name,
+			Language: strings.Join(repo.Languages, ", "),
+			Desc:     repo.About,
+		})
+		if err != nil {
+			return err
+		}
+		report.record("repository", outcome)
+		if err := replaceRepoLanguages(db, repoID, repo.LanguageBytes); err != nil {
+			return err
+		}
+	}
+	query := "DELETE FROM repository WHERE github_id = ?"
+	args := []interface{}{githubURL}
+	if len(pinned) > 0 {
+		query += " AND repo_id NOT IN ?"
+		args = append(args, pinned)
+	}
+	if err := db.Exec(query, args...).Error; err != nil {
+		return fmt.Errorf("could not remove unpinned repositories: %v", err)
+	}
+	return nil
+}
+
+// syncLeetCode fetches the student's LeetCode counts and upserts the leetcode
+// and problems rows
+func syncLeetCode(db *gorm.DB, srn, leetcodeURL string, report *importReport) error {
+	username, err := getUsernameFromURL(leetcodeURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFetchFailed, err)
+	}
+	leetProfile, err := leetcodeProvider.FetchProfile(context.Background(), username)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFetchFailed, err)
+	}
+	return storeLeetCodeProfile(db, srn, leetcodeURL, username, leetProfile, report)
+}
+
+// storeLeetCodeProfile upserts an already fetched LeetCode profile and records
+// a snapshot of it
+func storeLeetCodeProfile(db *gorm.DB, srn, leetcodeURL, username string, leetProfile LeetCodeProfile, report *importReport) error {
+	outcome, err := upsertLeetCode(db, LeetCode{
+		LeetCodeID: leetcodeURL,
+		StudentID:  srn,
+		Username:   leetProfile.Username,
+		Rank:       leetProfile.Ranking,
+	})
+	if err != nil {
+		return err
+	}
+	report.record("leetcode", outcome)
+
+	outcome, err = upsertProblems(db, Problems{
+		LeetcodeID: fmt.Sprintf("https://leetcode.com/%s", username),
+		NoEasy:     leetProfile.EasySolved,
+		NoMedium:   leetProfile.MediumSolved,
+		NoHard:     leetProfile.HardSolved,
+	})
+	if err != nil {
+		return err
+	}
+	report.record("problems", outcome)
+	return recordLeetCodeSnapshot(db, srn, leetProfile)
+}
+
+// upsertMentor creates a mentor the CSV files name, unless they exist already.
+// Mentors are only known by name there; log in needs a password set with
+// `passwd -mentor ID` afterwards. Databases older than the migrations may
+// have no unique key on mentor_name, so this cannot use ON CONFLICT.
+func upsertMentor(db *gorm.DB, name string) (importOutcome, error) {
+	res := db.Exec(`
+		INSERT INTO mentor (mentor_name)
+		SELECT CAST(? AS VARCHAR(100))
+		WHERE NOT EXISTS (SELECT 1 FROM mentor WHERE mentor_name = ?)`, name, name)
+	if res.Error != nil {
+		return outcomeSkipped, fmt.Errorf("couldn't insert into mentor: %v", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return outcomeSkipped, nil
+	}
+	return outcomeCreated, nil
+}
+
+// importMentors creates every mentor named in either CSV file, so that the
+// students and sessions that refer to them can be imported into an empty
+// database
+func importMentors(db *gorm.DB, all_info []Info, mentor_info []Mentor_Session_CSV, report *importReport) error {
+	var names []string
+	seen := map[string]bool{"": true}
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, info := range all_info {
+		add(info.MentorID)
+	}
+	for _, m_info := range mentor_info {
+		add(m_info.MentorID)
+	}
+	for _, name := range names {
+		outcome, err := upsertMentor(db, name)
+		if err != nil {
+			return fmt.Errorf("mentor %s: %v", name, err)
+		}
+		report.record("mentor", outcome)
+		if outcome == outcomeCreated {
+			id, err := fetchMentorID(db, name)
+			if err != nil {
+				return fmt.Errorf("mentor %s: %v", name, err)
+			}
+			log.Printf("Created mentor %s with ID %d, set their password with passwd -mentor %d", name, id, id)
+		}
+	}
+	return nil
+}
+
+// importStudent upserts one CSV student together with the github, repository,
+// leetcode and problems rows scraped from their profiles
+func importStudent(db *gorm.DB, info Info, report *importReport) error {
+	mentorID, err := fetchMentorID(db, info.MentorID)
+	if err != nil || mentorID == 0 {
+		log.Printf("Could not find mentor ID for %s, skipping %s", info.MentorID, info.StudentID)
+		report.record("student", outcomeSkipped)
+		return nil
+	}
+
+	student := Student{
+		StudentID: info.StudentID,
+		Name:      info.Name,
+		PhoneNo:   info.PhoneNo,
+		Dob:       info.DOB,
+		Gender:    info.Gender,
+		Resume:    info.Resume,
+		Sem:       info.Sem,
+		MentorID:  mentorID,
+		CGPA:      info.CGPA,
+		Email:     info.Email,
+		Age:       info.Age,
+		Linkedin:  info.Linkedin,
+		Degree:    info.Degree,
+		Stream:    info.Stream,
+	}
+	outcome, err := upsertStudent(db, student)
+	if err != nil {
+		return err
+	}
+	report.record("student", outcome)
+
+	if err := syncGithub(db, info.StudentID, info.GithubProfile, report); err != nil {
+		if !errors.Is(err, errFetchFailed) {
+			return err
+		}
+		log.Printf("Skipping GitHub data for student %s: %v", info.Name, err)
+	}
+	if err := syncLeetCode(db, info.StudentID, info.LeetcodeProfile, report); err != nil {
+		if !errors.Is(err, errFetchFailed) {
+			return err
+		}
+		log.Printf("Skipping LeetCode data for student %s: %v", info.Name, err)
+	}
+	if err := indexStudentResume(context.Background(), db, info.StudentID); err != nil {
+		log.Printf("Skipping resume index for student %s: %v", info.Name, err)
+	}
+	return nil
+}
+
+// importMentorSession upserts one CSV mentor session
+func importMentorSession(db *gorm.DB, m_info Mentor_Session_CSV, report *importReport) error {
+	m_id, err := fetchMentorID(db, m_info.MentorID)
+	if err != nil || m_id == 0 {
+		log.Printf("Could not find mentor ID for %s, skipping session of %s", m_info.MentorID, m_info.StudentID)
+		report.record("mentor_sessions", outcomeSkipped)
+		return nil
+	}
+	date, err := parseSessionDate(m_info.Date)
+	if err != nil {
+		log.Printf("Skipping session of %s: %v", m_info.StudentID, err)
+		report.record("mentor_sessions", outcomeSkipped)
+		return nil
+	}
+	outcome, err := upsertMentorSession(db, Mentor_Session_DB{
+		MentorID:  m_id,
+		StudentID: m_info.StudentID,
+		Date:      date,
+		Advice:    m_info.Advice,
+	})
+	if err != nil {
+		return err
+	}
+	report.record("mentor_sessions", outcome)
+	return nil
+}
+
+// runImport seeds the database from the student and mentor session CSV files.
+// Every row is upserted, so running it again only applies what changed.
+// Mentors are created first, from the names in both files.
+func runImport(db *gorm.DB, dataPath, mentorPath string) (*importReport, error) {
+	var (
+		wg                 sync.WaitGroup
+		all_info           []Info
+		mentor_info        []Mentor_Session_CSV
+		infoErr, mentorErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		all_info, infoErr = readInfoCSV(dataPath)
+	}()
+	go func() {
+		defer wg.Done()
+		mentor_info, mentorErr = readMentorSessionsCSV(mentorPath)
+	}()
+	wg.Wait()
+	if err := errors.Join(infoErr, mentorErr); err != nil {
+		return nil, err
+	}
+
+	report := newImportReport()
+	if err := importMentors(db, all_info, mentor_info, report); err != nil {
+		return report, err
+	}
+	for _, info := range all_info {
+		if err := importStudent(db, info, report); err != nil {
+			return report, fmt.Errorf("student %s: %v", info.StudentID, err)
+		}
+	}
+	for _, m_info := range mentor_info {
+		if err := importMentorSession(db, m_info, report); err != nil {
+			return report, fmt.Errorf("mentor session of %s: %v", m_info.StudentID, err)
+		}
+	}
+	return report, nil
+}
+
+// importCommand implements `backend import [-data file] [-mentors file]`
+func importCommand(cfg Config, args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dataPath := fs.String("data", "data.csv", "student CSV file")
+	mentorPath := fs.String("mentors", "mentor_sesh.csv", "mentor session CSV file")
+	fs.Parse(args)
+
+	db := connectDB(cfg.Database)
+	migrateDatabase(db)
+	report, err := runImport(db, *dataPath, *mentorPath)
+	if report != nil {
+		report.print(os.Stdout)
+	}
+	if err != nil {
+		log.Fatalf("import failed: %v", err)
+	}
+	fmt.Println("import finished")
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpsertStudentAppliesDobChange(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+
+	student, _, err := newSQLStore(db).Student("PES2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome, err := upsertStudent(db, student); err != nil || outcome != outcomeSkipped {
+		t.Fatalf("unchanged upsertStudent = %v, %v, want skipped", outcome, err)
+	}
+	student.Dob = time.Date(2003, 11, 20, 0, 0, 0, 0, time.UTC)
+	if outcome, err := upsertStudent(db, student); err != nil || outcome != outcomeUpdated {
+		t.Fatalf("upsertStudent with a new dob = %v, %v, want updated", outcome, err)
+	}
+	got, _, err := newSQLStore(db).Student("PES2")
+	if err != nil || !got.Dob.Equal(student.Dob) {
+		t.Errorf("dob = %v, %v, want %v", got.Dob, err, student.Dob)
+	}
+}
+
+func TestInsertProblemsAssignsIDs(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	if err := insertLeetCode(db, LeetCode{LeetCodeID: "https://leetcode.com/bala", StudentID: "PES2", Username: "bala"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := insertProblems(db, Problems{LeetcodeID: "https://leetcode.com/bala", NoEasy: 3}); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	if err := db.Raw("SELECT problem_id FROM problems ORDER BY problem_id").Scan(&ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Errorf("problem ids = %v, want two distinct ids", ids)
+	}
+}
+
+func TestRunImportCreatesMentorsInEmptyDatabase(t *testing.T) {
+	db := newSQLiteDB(t)
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data.csv")
+	mentorPath := filepath.Join(dir, "mentor_sesh.csv")
+	data := `name,srn,cgpa,sem,age,email,ph_no,degree,stream,gender,github_profile,leetcode_profile,mentor_name,resume,linkedin_link
+Anu,PES1,9.1,5,20,anu@example.com,999,B.Tech,Computer Science,Female,,,Raj,,
+Bala,PES2,8.2,5,20,bala@example.com,998,B.Tech,Computer Science,Male,,,Vincent,,
+`
+	sessions := `name,srn,mentor_name,date,advice
+Anu,PES1,Raj,2024-11-06,"Needs to improve focus"
+Bala,PES2,Meera,2024-11-07,"Practice graphs"
+`
+	for path, content := range map[string]string{dataPath: data, mentorPath: sessions} {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := runImport(db, dataPath, mentorPath)
+	if err != nil {
+		t.Fatalf("runImport: %v", err)
+	}
+	for table, want := range map[string]importCounts{
+		"mentor":          {Created: 3},
+		"student":         {Created: 2},
+		"mentor_sessions": {Created: 2},
+	} {
+		if got := report.counts[table]; got == nil || *got != want {
+			t.Errorf("%s counts = %+v, want %+v", table, got, want)
+		}
+	}
+	id, err := fetchMentorID(db, "Raj")
+	if err != nil || id == 0 {
+		t.Fatalf("mentor Raj = %d, %v", id, err)
+	}
+	if err := setMentorPassword(db, id, "raj-secret"); err != nil {
+		t.Errorf("setMentorPassword: %v", err)
+	}
+
+	report, err = runImport(db, dataPath, mentorPath)
+	if err != nil {
+		t.Fatalf("second runImport: %v", err)
+	}
+	if got := report.counts["mentor"]; got == nil || *got != (importCounts{Skipped: 3}) {
+		t.Errorf("mentor counts on re-import = %+v, want 3 skipped", got)
+	}
+}
+
+func TestUpsertMentorWithoutUniqueName(t *testing.T) {
+	// A mentor table created before the migrations, without the unique key
+	db, err := openDB(DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "legacy.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.Exec("CREATE TABLE mentor (mentor_id INTEGER PRIMARY KEY, mentor_name VARCHAR(100) NOT NULL)").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []importOutcome{outcomeCreated, outcomeSkipped} {
+		if outcome, err := upsertMentor(db, "Raj"); err != nil || outcome != want {
+			t.Errorf("upsertMentor = %v, %v, want %v", outcome, err, want)
+		}
+	}
+	var n int64
+	if err := db.Raw("SELECT COUNT(*) FROM mentor WHERE mentor_name = 'Raj'").Scan(&n).Error; err != nil || n != 1 {
+		t.Errorf("%d mentors named Raj, %v", n, err)
+	}
+}
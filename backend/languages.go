@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// RepoLanguage is the share of one language in a repository
+type RepoLanguage struct {
+	RepoID     string  `gorm:"primaryKey;column:repo_id" json:"-"`
+	Language   string  `gorm:"primaryKey;column:language" json:"language"`
+	Bytes      int64   `gorm:"column:bytes" json:"bytes"`
+	Percentage float64 `gorm:"type:numeric(5,2);column:percentage" json:"percentage"`
+}
+
+func (RepoLanguage) TableName() string {
+	return "repo_language"
+}
+
+// languageShares turns byte counts into rows ordered largest first
+func languageShares(langBytes map[string]int) []RepoLanguage {
+	total := 0
+	for _, b := range langBytes {
+		total += b
+	}
+	var shares []RepoLanguage
+	for _, lang := range languagesBySize(langBytes) {
+		pct := 0.0
+		if total > 0 {
+			pct = math.Round(float64(langBytes[lang])*10000/float64(total)) / 100
+		}
+		shares = append(shares, RepoLanguage{Language: lang, Bytes: int64(langBytes[lang]), Percentage: pct})
+	}
+	return shares
+}
+
+// replaceRepoLanguages stores the language breakdown of a repository,
+// replacing whatever was stored before
+func replaceRepoLanguages(db *gorm.DB, repoID string, langBytes map[string]int) error {
+	if err := db.Exec("DELETE FROM repo_language WHERE repo_id = ?", repoID).Error; err != nil {
+		return fmt.Errorf("could not clear repo languages: %v", err)
+	}
+	for _, share := range languageShares(langBytes) {
+		err := db.Exec(`INSERT INTO repo_language (repo_id, language, bytes, percentage)
+			VALUES ($1, $2, $3, $4)`, repoID, share.Language, share.Bytes, share.Percentage).Error
+		if err != nil {
+			return fmt.Errorf("could not insert repo language: %v", err)
+		}
+	}
+	return nil
+}
+
+// LanguageShare is a language's part of all the code in a student's repositories
+type LanguageShare struct {
+	Language   string  `json:"language"`
+	Bytes      int64   `json:"bytes"`
+	Percentage float64 `json:"percentage"`
+}
+
+// fetchLanguageProfile sums the language bytes over all repositories of a student
+func fetchLanguageProfile(db *gorm.DB, srn string) ([]LanguageShare, int64, error) {
+	var rows []struct {
+		Language string
+		Bytes    int64
+	}
+	err := db.Raw(`
+		SELECT rl.language, SUM(rl.bytes) AS bytes
+		FROM github g
+		JOIN repository r ON r.github_id = g.github_id
+		JOIN repo_language rl ON rl.repo_id = r.repo_id
+		WHERE g.student_id = ?
+		GROUP BY rl.language
+		ORDER BY bytes DESC, rl.language`, srn).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	for _, row := range rows {
+		total += row.Bytes
+	}
+	shares := []LanguageShare{}
+	for _, row := range rows {
+		pct := 0.0
+		if total > 0 {
+			pct = math.Round(float64(row.Bytes)*10000/float64(total)) / 100
+		}
+		shares = append(shares, LanguageShare{Language: row.Language, Bytes: row.Bytes, Percentage: pct})
+	}
+	return shares, total, nil
+}
+
+// languageSummary renders the top languages as "62% Go, 30% TypeScript"
+func languageSummary(shares []LanguageShare, limit int) string {
+	var parts []string
+	for i, share := range shares {
+		if i == limit {
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%.0f%% %s", share.Percentage, share.Language))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// GetLanguageProfile returns the language breakdown of a student's repositories
+func GetLanguageProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn := r.URL.Query().Get("srn")
+	if srn == "" {
+		http.Error(w, "Missing srn parameter", http.StatusBadRequest)
+		return
+	}
+	shares, total, err := fetchLanguageProfile(db, srn)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	response := map[string]interface{}{
+		"srn":         srn,
+		"total_bytes": total,
+		"languages":   shares,
+		"summary":     languageSummary(shares, 3),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// FindStudentsByLanguage lists the students whose code is at least
+// min_percent (default 1) in the given language, biggest share first
+func FindStudentsByLanguage(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	language := r.URL.Query().Get("language")
+	if language == "" {
+		http.Error(w, "Missing language parameter", http.StatusBadRequest)
+		return
+	}
+	minPercent := 1.0
+	if v := r.URL.Query().Get("min_percent"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil || p < 0 || p > 100 {
+			http.Error(w, "min_percent must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		minPercent = p
+	}
+
+	type StudentLanguage struct {
+		SRN        string  `json:"srn"`
+		Name       string  `json:"name"`
+		Bytes      int64   `json:"bytes"`
+		Percentage float64 `json:"percentage"`
+	}
+	students := []StudentLanguage{}
+	err := db.Raw(`
+		WITH per_student AS (
+			SELECT g.student_id, rl.language, SUM(rl.bytes) AS bytes
+			FROM github g
+			JOIN repository r ON r.github_id = g.github_id
+			JOIN repo_language rl ON rl.repo_id = r.repo_id
+			GROUP BY g.student_id, rl.language
+		), shares AS (
+			SELECT student_id, language, bytes,
+				ROUND(bytes * 100.0 / NULLIF(SUM(bytes) OVER (PARTITION BY student_id), 0), 2) AS percentage
+			FROM per_student
+		)
+		SELECT s.student_id AS srn, s.name, sh.bytes, sh.percentage
+		FROM shares sh
+		JOIN student s ON s.student_id = sh.student_id
+		WHERE LOWER(sh.language) = LOWER(?) AND sh.percentage >= ?
+		ORDER BY sh.percentage DESC, s.student_id`, language, minPercent).Scan(&students).Error
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	response := map[string]interface{}{
+		"language":    language,
+		"min_percent": minPercent,
+		"students":    students,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
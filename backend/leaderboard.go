@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLeaderboardPageSize = 15
+	maxLeaderboardPageSize     = 100
+)
+
+// leaderboardRow is everything a leaderboard can rank a student by
+type leaderboardRow struct {
+	SRN            string
+	Name           string
+	CGPA           float64
+	Sem            int
+	Degree         string
+	Stream         string
+	MentorID       int
+	Ranking        int
+	EasySolved     int
+	MediumSolved   int
+	HardSolved     int
+	Repos          int
+	MentorSessions int
+	Languages      []string `gorm:"-"`
+}
+
+// leaderboardMetric is one way of ranking students. value reports false for
+// students that cannot be ranked by the metric.
+type leaderboardMetric struct {
+	lowerIsBetter bool
+	value         func(row leaderboardRow) (float64, bool)
+}
+
+var leaderboardMetrics = map[string]leaderboardMetric{
+	"cgpa": {value: func(row leaderboardRow) (float64, bool) {
+		return row.CGPA, true
+	}},
+	"leetcode_ranking": {lowerIsBetter: true, value: func(row leaderboardRow) (float64, bool) {
+		return float64(row.Ranking), row.Ranking > 0
+	}},
+	"total_solved": {value: func(row leaderboardRow) (float64, bool) {
+		return float64(row.EasySolved + row.MediumSolved + row.HardSolved), true
+	}},
+	"hard_solved": {value: func(row leaderboardRow) (float64, bool) {
+		return float64(row.HardSolved), true
+	}},
+	"github_repos": {value: func(row leaderboardRow) (float64, bool) {
+		return float64(row.Repos), true
+	}},
+	"score": {value: func(row leaderboardRow) (float64, bool) {
+		in := ScoreInputs{
+			CGPA:           row.CGPA,
+			EasySolved:     row.EasySolved,
+			MediumSolved:   row.MediumSolved,
+			HardSolved:     row.HardSolved,
+			Ranking:        row.Ranking,
+			PinnedRepos:    row.Repos,
+			Languages:      row.Languages,
+			MentorSessions: row.MentorSessions,
+		}
+		return computeScore(in, scoreConfig.Weights, scoreConfig.Targets).Score, true
+	}},
+}
+
+func leaderboardMetricNames() []string {
+	var names []string
+	for name := range leaderboardMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LeaderboardEntry is a ranked student
+type LeaderboardEntry struct {
+	Rank   int     `json:"rank"`
+	SRN    string  `json:"srn"`
+	Name   string  `json:"name"`
+	Sem    int     `json:"sem"`
+	Degree string  `json:"degree"`
+	Stream string  `json:"stream"`
+	Value  float64 `json:"value"`
+}
+
+// rankLeaderboard orders the students by the metric and gives them dense
+// ranks, so students with the same value share a rank and the next value gets
+// the next rank. Ties are listed by SRN. Students the metric cannot rank are
+// counted separately.
+func rankLeaderboard(rows []leaderboardRow, metric leaderboardMetric) (entries []LeaderboardEntry, unranked int) {
+	for _, row := range rows {
+		value, ok := metric.value(row)
+		if !ok {
+			unranked++
+			continue
+		}
+		entries = append(entries, LeaderboardEntry{
+			SRN: row.SRN, Name: row.Name, Sem: row.Sem, Degree: row.Degree, Stream: row.Stream, Value: value,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Value != b.Value {
+			return (a.Value < b.Value) == metric.lowerIsBetter
+		}
+		return a.SRN < b.SRN
+	})
+	for i := range entries {
+		switch {
+		case i == 0:
+			entries[i].Rank = 1
+		case entries[i].Value == entries[i-1].Value:
+			entries[i].Rank = entries[i-1].Rank
+		default:
+			entries[i].Rank = entries[i-1].Rank + 1
+		}
+	}
+	return entries, unranked
+}
+
+// percentile is the share of ranked students strictly behind the student at i
+func percentile(entries []LeaderboardEntry, i int) float64 {
+	if len(entries) < 2 {
+		return 100
+	}
+	behind := 0
+	for _, e := range entries[i+1:] {
+		if e.Rank > entries[i].Rank {
+			behind++
+		}
+	}
+	return math.Round(float64(behind)*10000/float64(len(entries)-1)) / 100
+}
+
+// leaderboardFilter narrows the leaderboard down to a group of students
+type leaderboardFilter struct {
+	Sem      int
+	Degree   string
+	Stream   string
+	MentorID int
+}
+
+func (f leaderboardFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Sem > 0 {
+		conds, args = append(conds, "s.sem = ?"), append(args, f.Sem)
+	}
+	if f.Degree != "" {
+		conds, args = append(conds, "LOWER(s.degree) = LOWER(?)"), append(args, f.Degree)
+	}
+	if f.Stream != "" {
+		conds, args = append(conds, "LOWER(s.stream) = LOWER(?)"), append(args, f.Stream)
+	}
+	if f.MentorID > 0 {
+		conds, args = append(conds, "s.mentor_id = ?"), append(args, f.MentorID)
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+// fetchLeaderboardRows loads the ranking data of every student matching the
+// filter. Students without a LeetCode or GitHub profile are included with
+// zero counts.
+func fetchLeaderboardRows(db *gorm.DB, filter leaderboardFilter) ([]leaderboardRow, error) {
+	where, args := filter.where()
+	var rows []leaderboardRow
+	err := db.Raw(`
+		SELECT s.student_id AS srn, s.name, s.cgpa, s.sem, s.degree, s.stream, s.mentor_id,
+			COALESCE((SELECT MIN(l.ranking) FROM leetcode l WHERE l.student_id = s.student_id), 0) AS ranking,
+			COALESCE(lp.easy, 0) AS easy_solved, COALESCE(lp.medium, 0) AS medium_solved, COALESCE(lp.hard, 0) AS hard_solved,
+			(SELECT COUNT(*) FROM github g JOIN repository r ON r.github_id = g.github_id
+				WHERE g.student_id = s.student_id) AS repos,
+			(SELECT COUNT(*) FROM mentor_sessions m WHERE m.student_id = s.student_id) AS mentor_sessions
+		FROM student s
+		LEFT JOIN (
+			SELECT l.student_id, SUM(p.no_easy) AS easy, SUM(p.no_medium) AS medium, SUM(p.no_hard) AS hard
+			FROM leetcode l
+			JOIN problems p ON p.leetcode_id = l.leetcode_id
+			GROUP BY l.student_id
+		) lp ON lp.student_id = s.student_id
+		`+where, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var repoLanguages []struct {
+		SRN      string
+		Language string
+	}
+	err = db.Raw(`
+		SELECT g.student_id AS srn, r.language
+		FROM student s
+		JOIN github g ON g.student_id = s.student_id
+		JOIN repository r ON r.github_id = g.github_id
+		`+where, args...).Scan(&repoLanguages).Error
+	if err != nil {
+		return nil, err
+	}
+	languages := map[string]map[string]bool{}
+	for _, rl := range repoLanguages {
+		for _, lang := range strings.Split(rl.Language, ",") {
+			if lang = strings.TrimSpace(lang); lang != "" {
+				if languages[rl.SRN] == nil {
+					languages[rl.SRN] = map[string]bool{}
+				}
+				languages[rl.SRN][lang] = true
+			}
+		}
+	}
+	for i := range rows {
+		for lang := range languages[rows[i].SRN] {
+			rows[i].Languages = append(rows[i].Languages, lang)
+		}
+	}
+	return rows, nil
+}
+
+// LeaderboardPosition is where the requesting student stands
+type LeaderboardPosition struct {
+	SRN        string  `json:"srn"`
+	Rank       int     `json:"rank"`
+	Value      float64 `json:"value"`
+	Percentile float64 `json:"percentile"`
+}
+
+// positiveIntParam reads an optional positive integer query parameter
+func positiveIntParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+	return n, nil
+}
+
+// GetLeaderboard implements GET /leaderboard. It ranks students by ?metric=
+// (cgpa by default), optionally only those of a ?sem=, ?degree=, ?stream= or
+// ?mentor=, and returns one ?page= of ?page_size= entries. Students see their
+// own position, mentors that of ?srn= if given.
+func GetLeaderboard(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	metricName := query.Get("metric")
+	if metricName == "" {
+		metricName = "cgpa"
+	}
+	metric, ok := leaderboardMetrics[metricName]
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: fmt.Sprintf("unknown metric %q, supported: %s",
+			metricName, strings.Join(leaderboardMetricNames(), ", "))})
+		return
+	}
+
+	var filter leaderboardFilter
+	var err error
+	if filter.Sem, err = positiveIntParam(r, "sem", 0); err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	page, err := positiveIntParam(r, "page", 1)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	pageSize, err := positiveIntParam(r, "page_size", defaultLeaderboardPageSize)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	if pageSize > maxLeaderboardPageSize {
+		pageSize = maxLeaderboardPageSize
+	}
+	filter.Degree = strings.TrimSpace(query.Get("degree"))
+	filter.Stream = strings.TrimSpace(query.Get("stream"))
+	if mentor := strings.TrimSpace(query.Get("mentor")); mentor != "" {
+		if filter.MentorID, err = strconv.Atoi(mentor); err != nil {
+			if filter.MentorID, err = fetchMentorID(db, mentor); err != nil || filter.MentorID == 0 {
+				writeAPIError(w, http.StatusBadRequest, apiError{Error: fmt.Sprintf("unknown mentor %q", mentor)})
+				return
+			}
+		}
+	}
+
+	srn := strings.ToUpper(query.Get("srn"))
+	if s, _ := sessionFromContext(r.Context()); s.Role == roleStudent {
+		srn = s.Subject
+	}
+
+	rows, err := fetchLeaderboardRows(db, filter)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	entries, unranked := rankLeaderboard(rows, metric)
+
+	var me *LeaderboardPosition
+	for i, e := range entries {
+		if e.SRN == srn {
+			me = &LeaderboardPosition{SRN: e.SRN, Rank: e.Rank, Value: e.Value, Percentile: percentile(entries, i)}
+			break
+		}
+	}
+	// Checked before multiplying, a huge page would overflow
+	start := len(entries)
+	if page-1 <= len(entries)/pageSize {
+		start = min((page-1)*pageSize, len(entries))
+	}
+	end := start + pageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"metric":    metricName,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     len(entries),
+		"unranked":  unranked,
+		"entries":   append([]LeaderboardEntry{}, entries[start:end]...),
+		"me":        me,
+	})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRankLeaderboardDenseRanks(t *testing.T) {
+	rows := []leaderboardRow{
+		{SRN: "PES4", CGPA: 8.2, Ranking: 5000},
+		{SRN: "PES2", CGPA: 9.1, Ranking: 1200},
+		{SRN: "PES3", CGPA: 7.5},
+		{SRN: "PES1", CGPA: 9.1, Ranking: 1200},
+		{SRN: "PES5", CGPA: 8.2, Ranking: 300},
+	}
+	ranks := func(entries []LeaderboardEntry) []string {
+		var got []string
+		for _, e := range entries {
+			got = append(got, strconv.Itoa(e.Rank)+" "+e.SRN)
+		}
+		return got
+	}
+
+	entries, unranked := rankLeaderboard(rows, leaderboardMetrics["cgpa"])
+	want := []string{"1 PES1", "1 PES2", "2 PES4", "2 PES5", "3 PES3"}
+	if got := ranks(entries); !reflect.DeepEqual(got, want) || unranked != 0 {
+		t.Errorf("cgpa ranks = %q, %d unranked, want %q", got, unranked, want)
+	}
+
+	// A lower LeetCode ranking is better, and students without one are left out
+	entries, unranked = rankLeaderboard(rows, leaderboardMetrics["leetcode_ranking"])
+	want = []string{"1 PES5", "2 PES1", "2 PES2", "3 PES4"}
+	if got := ranks(entries); !reflect.DeepEqual(got, want) || unranked != 1 {
+		t.Errorf("leetcode ranks = %q, %d unranked, want %q and 1 unranked", got, unranked, want)
+	}
+
+	tests := []struct {
+		i    int
+		want float64
+	}{
+		{0, 100},   // PES5 is ahead of everyone
+		{1, 33.33}, // PES1 and PES2 tie, only PES4 is behind them
+		{2, 33.33},
+		{3, 0},
+	}
+	for _, tt := range tests {
+		if got := percentile(entries, tt.i); got != tt.want {
+			t.Errorf("percentile of %s = %v, want %v", entries[tt.i].SRN, got, tt.want)
+		}
+	}
+	if got := percentile(entries[:1], 0); got != 100 {
+		t.Errorf("percentile of a single entry = %v, want 100", got)
+	}
+}
+
+func TestLeaderboardPageOutOfRange(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	r := newTestRouter(t, newSQLStore(db)).(*mux.Router)
+	r.HandleFunc("/leaderboard", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetLeaderboard(db, w, r)
+	})).Methods("GET")
+	token := testToken(t, "1", roleMentor)
+
+	for _, page := range []string{"2", "3", "4611686018427387904", strconv.Itoa(int(^uint(0) >> 1))} {
+		rec := serveRequest(r, "GET", "/leaderboard?page_size=2&page="+page, token, "")
+		var resp struct {
+			Total   int                `json:"total"`
+			Entries []LeaderboardEntry `json:"entries"`
+		}
+		decodeResponse(t, rec, &resp)
+		want := 0
+		if page == "2" {
+			want = 1
+		}
+		if rec.Code != http.StatusOK || resp.Total != 3 || len(resp.Entries) != want {
+			t.Errorf("page %s = %d with %d of %d entries, want %d", page, rec.Code, len(resp.Entries), resp.Total, want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LeetCodeProvider fetches the solved counts and ranking of a LeetCode user
+type LeetCodeProvider interface {
+	FetchProfile(ctx context.Context, username string) (LeetCodeProfile, error)
+}
+
+// leetcodeProvider is used by onboarding, the importer and the refresh scheduler
+var leetcodeProvider LeetCodeProvider = newLeetCodeGraphQLProvider()
+
+var errLeetCodeUserNotFound = errors.New("leetcode user not found")
+
+// newLeetCodeProvider picks the configured provider: "graphql" (the
+// default), "proxy" or "fixture"
+func newLeetCodeProvider(cfg LeetCodeConfig) (LeetCodeProvider, error) {
+	switch cfg.Provider {
+	case "", "graphql":
+		return newLeetCodeGraphQLProvider(), nil
+	case "proxy":
+		if cfg.ProxyURL == "" {
+			return nil, errors.New("a proxy url is required for the proxy provider")
+		}
+		return newLeetCodeProxyProvider(cfg.ProxyURL), nil
+	case "fixture":
+		dir := cfg.Fixtures
+		if dir == "" {
+			dir = filepath.Join("testdata", "leetcode")
+		}
+		return fixtureLeetCodeProvider{dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown leetcode provider %q", cfg.Provider)
+	}
+}
+
+// leetcodeGraphQLProvider talks to LeetCode's public GraphQL endpoint
+type leetcodeGraphQLProvider struct {
+	httpClient *http.Client
+	endpoint   string
+}
+
+func newLeetCodeGraphQLProvider() *leetcodeGraphQLProvider {
+	return &leetcodeGraphQLProvider{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		endpoint:   "https://leetcode.com/graphql",
+	}
+}
+
+const leetcodeProfileQuery = `query userProfile($username: String!) {
+  matchedUser(username: $username) {
+    username
+    profile { ranking }
+    submitStatsGlobal { acSubmissionNum { difficulty count } }
+  }
+}`
+
+func (p *leetcodeGraphQLProvider) FetchProfile(ctx context.Context, username string) (LeetCodeProfile, error) {
+	var profile LeetCodeProfile
+	payload, err := json.Marshal(map[string]interface{}{
+		"query":     leetcodeProfileQuery,
+		"variables": map[string]string{"username": username},
+	})
+	if err != nil {
+		return profile, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return profile, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", "https://leetcode.com/"+url.PathEscape(username)+"/")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return profile, fmt.Errorf("leetcode graphql: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return profile, fmt.Errorf("leetcode graphql: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			MatchedUser *struct {
+				Username string `json:"username"`
+				Profile  struct {
+					Ranking int `json:"ranking"`
+				} `json:"profile"`
+				SubmitStatsGlobal struct {
+					AcSubmissionNum []struct {
+						Difficulty string `json:"difficulty"`
+						Count      int    `json:"count"`
+					} `json:"acSubmissionNum"`
+				} `json:"submitStatsGlobal"`
+			} `json:"matchedUser"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return profile, fmt.Errorf("leetcode graphql: parsing response: %v", err)
+	}
+	user := result.Data.MatchedUser
+	if user == nil {
+		if len(result.Errors) > 0 && !strings.Contains(strings.ToLower(result.Errors[0].Message), "does not exist") {
+			return profile, fmt.Errorf("leetcode graphql: %s", result.Errors[0].Message)
+		}
+		return profile, fmt.Errorf("%w: %s", errLeetCodeUserNotFound, username)
+	}
+
+	profile.Username = user.Username
+	profile.Ranking = user.Profile.Ranking
+	for _, n := range user.SubmitStatsGlobal.AcSubmissionNum {
+		switch n.Difficulty {
+		case "All":
+			profile.TotalSolved = n.Count
+		case "Easy":
+			profile.EasySolved = n.Count
+		case "Medium":
+			profile.MediumSolved = n.Count
+		case "Hard":
+			profile.HardSolved = n.Count
+		}
+	}
+	return profile, nil
+}
+
+// leetcodeProxyProvider calls a proxy that answers GET <base>/<username> with
+// the LeetCodeProfile fields as JSON
+type leetcodeProxyProvider struct {
+	httpClient *http.Client
+	baseURL    string
+	maxRetries int
+	retryDelay time.Duration
+}
+
+func newLeetCodeProxyProvider(baseURL string) *leetcodeProxyProvider {
+	return &leetcodeProxyProvider{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		maxRetries: 3,
+		retryDelay: 2 * time.Second,
+	}
+}
+
+func (p *leetcodeProxyProvider) fetchOnce(ctx context.Context, username string) (LeetCodeProfile, bool, error) {
+	var profile LeetCodeProfile
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/"+url.PathEscape(username), nil)
+	if err != nil {
+		return profile, false, err
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return profile, true, fmt.Errorf("leetcode proxy: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return profile, false, fmt.Errorf("%w: %s", errLeetCodeUserNotFound, username)
+	case resp.StatusCode >= 500:
+		return profile, true, fmt.Errorf("leetcode proxy: status %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return profile, false, fmt.Errorf("leetcode proxy: status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return profile, true, fmt.Errorf("leetcode proxy: reading response: %v", err)
+	}
+	if err := json.Unmarshal(body, &profile); err != nil {
+		return profile, false, fmt.Errorf("leetcode proxy: parsing response: %v", err)
+	}
+	profile.Username = username
+	return profile, false, nil
+}
+
+// FetchProfile retries network errors and 5xx responses
+func (p *leetcodeProxyProvider) FetchProfile(ctx context.Context, username string) (LeetCodeProfile, error) {
+	var lastErr error
+	for i := 0; i < p.maxRetries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return LeetCodeProfile{}, ctx.Err()
+			case <-time.After(p.retryDelay):
+			}
+		}
+		profile, retry, err := p.fetchOnce(ctx, username)
+		if err == nil || !retry {
+			return profile, err
+		}
+		lastErr = err
+	}
+	return LeetCodeProfile{}, lastErr
+}
+
+// fixtureLeetCodeProvider serves profiles from <dir>/<username>.json, for
+// tests and offline demos
+type fixtureLeetCodeProvider struct {
+	dir string
+}
+
+func (p fixtureLeetCodeProvider) FetchProfile(ctx context.Context, username string) (LeetCodeProfile, error) {
+	var profile LeetCodeProfile
+	data, err := os.ReadFile(filepath.Join(p.dir, filepath.Base(username)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return profile, fmt.Errorf("%w: %s", errLeetCodeUserNotFound, username)
+	}
+	if err != nil {
+		return profile, err
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return profile, fmt.Errorf("leetcode fixture %s: %v", username, err)
+	}
+	profile.Username = username
+	return profile, nil
+}
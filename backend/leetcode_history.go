@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeetCodeSnapshot is the state of a LeetCode profile at one fetch
+type LeetCodeSnapshot struct {
+	SnapshotID int64     `gorm:"primaryKey;column:snapshot_id" json:"-"`
+	StudentID  string    `gorm:"column:student_id" json:"-"`
+	TakenAt    time.Time `gorm:"column:taken_at" json:"taken_at"`
+	Easy       int       `gorm:"column:no_easy" json:"easy_solved"`
+	Medium     int       `gorm:"column:no_medium" json:"medium_solved"`
+	Hard       int       `gorm:"column:no_hard" json:"hard_solved"`
+	Total      int       `gorm:"column:total_solved" json:"total_solved"`
+	Ranking    int       `gorm:"column:ranking" json:"ranking"`
+}
+
+func (LeetCodeSnapshot) TableName() string {
+	return "leetcode_snapshots"
+}
+
+// LeetCodeDelta is the progress over the last WindowDays days. Complete is
+// false when the history does not reach back that far, in which case the
+// delta is measured from the oldest snapshot.
+type LeetCodeDelta struct {
+	WindowDays    int       `json:"window_days"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	Complete      bool      `json:"complete"`
+	EasySolved    int       `json:"easy_solved"`
+	MediumSolved  int       `json:"medium_solved"`
+	HardSolved    int       `json:"hard_solved"`
+	TotalSolved   int       `json:"total_solved"`
+	RankingChange int       `json:"ranking_change"` // negative means the ranking improved
+}
+
+var defaultHistoryWindows = []int{7, 30, 90}
+
+// recordLeetCodeSnapshot stores the counts of a successful LeetCode fetch
+func recordLeetCodeSnapshot(db *gorm.DB, srn string, profile LeetCodeProfile) error {
+	total := profile.TotalSolved
+	if total == 0 {
+		total = profile.EasySolved + profile.MediumSolved + profile.HardSolved
+	}
+	err := db.Exec(`
+		INSERT INTO leetcode_snapshots (student_id, taken_at, no_easy, no_medium, no_hard, total_solved, ranking)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		srn, time.Now(), profile.EasySolved, profile.MediumSolved, profile.HardSolved, total, profile.Ranking).Error
+	if err != nil {
+		return fmt.Errorf("could not insert leetcode snapshot: %v", err)
+	}
+	return nil
+}
+
+// computeLeetCodeDeltas measures the progress between the newest snapshot and
+// the last snapshot taken before each window started. snapshots must be sorted
+// oldest first.
+func computeLeetCodeDeltas(snapshots []LeetCodeSnapshot, windows []int, now time.Time) []LeetCodeDelta {
+	deltas := []LeetCodeDelta{}
+	if len(snapshots) == 0 {
+		return deltas
+	}
+	latest := snapshots[len(snapshots)-1]
+	for _, days := range windows {
+		cutoff := now.AddDate(0, 0, -days)
+		baseline := snapshots[0]
+		complete := false
+		for _, s := range snapshots {
+			if s.TakenAt.After(cutoff) {
+				break
+			}
+			baseline = s
+			complete = true
+		}
+		rankingChange := 0
+		if baseline.Ranking > 0 && latest.Ranking > 0 {
+			rankingChange = latest.Ranking - baseline.Ranking
+		}
+		deltas = append(deltas, LeetCodeDelta{
+			WindowDays:    days,
+			From:          baseline.TakenAt,
+			To:            latest.TakenAt,
+			Complete:      complete,
+			EasySolved:    latest.Easy - baseline.Easy,
+			MediumSolved:  latest.Medium - baseline.Medium,
+			HardSolved:    latest.Hard - baseline.Hard,
+			TotalSolved:   latest.Total - baseline.Total,
+			RankingChange: rankingChange,
+		})
+	}
+	return deltas
+}
+
+// parseHistoryWindows reads a comma separated list of window sizes in days
+func parseHistoryWindows(value string) ([]int, error) {
+	if value == "" {
+		return defaultHistoryWindows, nil
+	}
+	var windows []int
+	for _, part := range strings.Split(value, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || days < 1 || days > 3650 {
+			return nil, fmt.Errorf("invalid window %q, expected days between 1 and 3650", part)
+		}
+		windows = append(windows, days)
+	}
+	sort.Ints(windows)
+	return windows, nil
+}
+
+// GetLeetcodeHistory returns the LeetCode snapshots of a student with the
+// progress over each requested window (?windows=7,30,90)
+func GetLeetcodeHistory(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn := r.URL.Query().Get("srn")
+	if srn == "" {
+		http.Error(w, "Missing srn parameter", http.StatusBadRequest)
+		return
+	}
+	windows, err := parseHistoryWindows(r.URL.Query().Get("windows"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	snapshots := []LeetCodeSnapshot{}
+	err = db.Raw(`
+		SELECT taken_at, no_easy, no_medium, no_hard, total_solved, ranking
+		FROM leetcode_snapshots
+		WHERE student_id = ?
+		ORDER BY taken_at`, srn).Scan(&snapshots).Error
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{
+		"srn":       srn,
+		"snapshots": snapshots,
+		"deltas":    computeLeetCodeDeltas(snapshots, windows, time.Now()),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLeetCodeGraphQLProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Referer") == "" {
+			t.Errorf("unexpected request %s, referer %q", r.Method, r.Header.Get("Referer"))
+		}
+		w.Write([]byte(`{"data":{"matchedUser":{
+			"username":"yuvftw",
+			"profile":{"ranking":98412},
+			"submitStatsGlobal":{"acSubmissionNum":[
+				{"difficulty":"All","count":401},
+				{"difficulty":"Easy","count":151},
+				{"difficulty":"Medium","count":205},
+				{"difficulty":"Hard","count":45}]}}}}`))
+	}))
+	defer srv.Close()
+
+	p := newLeetCodeGraphQLProvider()
+	p.endpoint = srv.URL
+	profile, err := p.FetchProfile(context.Background(), "yuvftw")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	want := LeetCodeProfile{Username: "yuvftw", EasySolved: 151, MediumSolved: 205, HardSolved: 45, TotalSolved: 401, Ranking: 98412}
+	if profile != want {
+		t.Errorf("profile = %+v, want %+v", profile, want)
+	}
+}
+
+func TestLeetCodeGraphQLProviderUnknownUser(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"matchedUser":null},"errors":[{"message":"That user does not exist."}]}`))
+	}))
+	defer srv.Close()
+
+	p := newLeetCodeGraphQLProvider()
+	p.endpoint = srv.URL
+	if _, err := p.FetchProfile(context.Background(), "ghost"); !errors.Is(err, errLeetCodeUserNotFound) {
+		t.Errorf("err = %v, want errLeetCodeUserNotFound", err)
+	}
+}
+
+func TestLeetCodeProxyProviderRetries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.URL.Path != "/surajkadapa" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		w.Write([]byte(`{"totalSolved":312,"easySolved":140,"mediumSolved":148,"hardSolved":24,"ranking":184233}`))
+	}))
+	defer srv.Close()
+
+	p := newLeetCodeProxyProvider(srv.URL + "/")
+	p.retryDelay = time.Millisecond
+	profile, err := p.FetchProfile(context.Background(), "surajkadapa")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	if calls != 3 || profile.TotalSolved != 312 || profile.Username != "surajkadapa" {
+		t.Errorf("calls = %d, profile = %+v", calls, profile)
+	}
+}
+
+func TestLeetCodeProxyProviderSurfacesErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := newLeetCodeProxyProvider(srv.URL)
+	p.retryDelay = time.Millisecond
+	profile, err := p.FetchProfile(context.Background(), "surajkadapa")
+	if err == nil {
+		t.Fatalf("expected an error, got %+v", profile)
+	}
+}
+
+func TestFixtureLeetCodeProvider(t *testing.T) {
+	p := fixtureLeetCodeProvider{dir: filepath.Join("testdata", "leetcode")}
+	profile, err := p.FetchProfile(context.Background(), "kamya_Mudaliar")
+	if err != nil {
+		t.Fatalf("FetchProfile: %v", err)
+	}
+	if profile.EasySolved != 96 || profile.Ranking != 512904 || profile.Username != "kamya_Mudaliar" {
+		t.Errorf("profile = %+v", profile)
+	}
+	if _, err := p.FetchProfile(context.Background(), "nobody"); !errors.Is(err, errLeetCodeUserNotFound) {
+		t.Errorf("err = %v, want errLeetCodeUserNotFound", err)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
@@ -8,27 +9,20 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
-	"encoding/csv"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/glebarez/sqlite"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
-	"github.com/jszwec/csvutil"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -53,9 +47,11 @@ type ProfileData struct {
 
 // PinnedRepo stores information about a pinned repository
 type PinnedRepo struct {
-	Name      string
-	About     string
-	Languages []string
+	Name          string
+	About         string
+	Stars         int
+	Languages     []string       // largest first
+	LanguageBytes map[string]int // bytes of code per language
 }
 
 type Info struct {
@@ -85,10 +81,11 @@ type Mentor_Session_CSV struct {
 }
 
 type Mentor_Session_DB struct {
-	MentorID  int    `gorm:"column:mentor_name"`
-	StudentID string `gorm:"column:student_id;primaryKey"`
-	Date      string `gorm:"column:date"`
-	Advice    string `gorm:"advice"`
+	SessionID int64     `gorm:"column:session_id;primaryKey"`
+	MentorID  int       `gorm:"column:mentor_id"`
+	StudentID string    `gorm:"column:student_id"`
+	Date      time.Time `gorm:"type:date;column:date"`
+	Advice    string    `gorm:"advice"`
 }
 
 type Student struct {
@@ -167,6 +164,14 @@ func (Repository) TableName() string {
 	return "repository"
 }
 
+// nullableDate is t, or NULL for the zero time of a date that is not known
+func nullableDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
 func insertStudent(db *gorm.DB, student Student) error {
 	query := `
 		INSERT INTO student
@@ -174,7 +179,7 @@ func insertStudent(db *gorm.DB, student Student) error {
 		VALUES
 		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
-	err := db.Exec(query, student.StudentID, student.Name, student.PhoneNo, student.Dob, student.Gender,
+	err := db.Exec(query, student.StudentID, student.Name, student.PhoneNo, nullableDate(student.Dob), student.Gender,
 		student.Resume, student.Sem, student.MentorID, student.CGPA, student.Email, student.Age, student.Linkedin, student.Degree, student.Stream).Error
 
 	if err != nil {
@@ -197,69 +202,6 @@ func fetchMentorID(db *gorm.DB, name string) (int, error) {
 	return mentor.MentorID, nil
 }
 
-// fetchProfileData scrapes the user's profile page to get username, bio, repo count, and pinned repositories with details
-func fetchProfileData(username, token string) ProfileData {
-	url := fmt.Sprintf("https://github.com/%s", username)
-	var profile ProfileData
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Fatal("Failed to fetch profile:", err)
-	}
-	defer resp.Body.Close()
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		log.Fatal("Failed to parse HTML:", err)
-	}
-	profile.Username = doc.Find("span.vcard-username").Text()
-
-	profile.Bio = strings.TrimSpace(doc.Find("div.user-profile-bio").Text())
-
-	profile.RepoCount = strings.TrimSpace(doc.Find("span.Counter").First().Text())
-	doc.Find(".pinned-item-list-item-content").Each(func(i int, s *goquery.Selection) {
-		repo := PinnedRepo{}
-		// get repo name
-		repo.Name = strings.TrimSpace(s.Find(".repo").Text())
-		// get about section if available
-		repo.About = strings.TrimSpace(s.Find("p.pinned-item-desc").Text())
-		// fetch all languages used in the repository
-		repo.Languages = fetchRepoLanguages(username, repo.Name, token)
-		// add this repository to the profile's pinned repos list
-		profile.PinnedRepos = append(profile.PinnedRepos, repo)
-	})
-
-	return profile
-}
-
-// fetchRepoLanguages fetches all languages used in a repository from the GitHub API
-func fetchRepoLanguages(username, repoName, token string) []string {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/languages", username, repoName)
-
-	req, _ := http.NewRequest("GET", url, nil)
-	if token != "" {
-		req.Header.Add("Authorization", "token "+token)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("Failed to fetch languages for %s: %v", repoName, err)
-		return nil
-	}
-	defer resp.Body.Close()
-
-	var languagesMap map[string]int
-	body, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(body, &languagesMap); err != nil {
-		log.Printf("Error parsing languages for %s: %v", repoName, err)
-		return nil
-	}
-
-	var languages []string
-	for lang := range languagesMap {
-		languages = append(languages, lang)
-	}
-	return languages
-}
 func getUsernameFromURL(githubURL string) (string, error) {
 	parsedURL, err := url.Parse(githubURL)
 	if err != nil {
@@ -284,13 +226,14 @@ func insertGithub(db *gorm.DB, github Github) error {
 	return nil
 }
 
+// insertProblems leaves problem_id to the database, which assigns the next one
 func insertProblems(db *gorm.DB, problems Problems) error {
 	query := `
-		INSERT INTO problems (problem_id, leetcode_id, no_easy, no_medium, no_hard)
-		VALUES ($1, $2, $3, $4, $5);
+		INSERT INTO problems (leetcode_id, no_easy, no_medium, no_hard)
+		VALUES ($1, $2, $3, $4);
 	`
 
-	err := db.Exec(query, problems.ProblemID, problems.LeetcodeID, problems.NoEasy, problems.NoMedium, problems.NoHard).Error
+	err := db.Exec(query, problems.LeetcodeID, problems.NoEasy, problems.NoMedium, problems.NoHard).Error
 	if err != nil {
 		return fmt.Errorf("could not insert problem: %v", err)
 	}
@@ -306,37 +249,6 @@ func insertMentorSessions(db *gorm.DB, mentors Mentor_Session_DB) error {
 	return nil
 }
 
-func fetchLeetCodeProfileData(username string) LeetCodeProfile {
-	url := fmt.Sprintf("http://100.102.21.101:8080/%s", username)
-	var profile LeetCodeProfile
-
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		resp, err := http.Get(url)
-		if err != nil {
-			log.Printf("Failed to fetch LeetCode profile for %s: %v", username, err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode == http.StatusOK {
-			err = json.NewDecoder(resp.Body).Decode(&profile)
-			if err != nil {
-				log.Printf("Failed to parse LeetCode JSON for %s: %v", username, err)
-				return profile
-			}
-			profile.Username = username
-			return profile
-		} else {
-			log.Printf("LeetCode profile fetch error for %s: Status %d", username, resp.StatusCode)
-		}
-
-		// Wait before retrying
-		time.Sleep(2 * time.Second)
-	}
-	return profile
-}
-
 func (LeetCode) TableName() string {
 	return "leetcode"
 }
@@ -391,107 +303,71 @@ func decryptAES(ciphertext string, aesKey []byte) (string, error) {
 	return string(data), nil
 }
 
-// readPasswordHash reads the password hash from the file
-func readPasswordHash() (string, error) {
-	hashFilePath := "testing.hash"
-
-	hashBytes, err := os.ReadFile(hashFilePath)
-	if err != nil {
-		return "", err
-	}
-	return string(hashBytes), nil
-}
-
-// GetStudentName retrieves the student name based on the provided SRN and password
-func GetStudentName(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
-	encryptedPass := r.URL.Query().Get("password")
-	file, err := os.Open("testing.txt")
-	if err != nil {
-		log.Println("Error opening file: %v", err)
-	}
-	defer file.Close()
-	content, err := io.ReadAll(file)
+// GetStudentName retrieves the name of the logged in student
+func GetStudentName(store Store, w http.ResponseWriter, r *http.Request) {
+	srn := r.URL.Query().Get("srn")
+	student, found, err := store.Student(srn)
 	if err != nil {
-		log.Println("Error reading file: %v", err)
-	}
-	fileContent := string(content)
-	if fileContent != encryptedPass {
-		http.Error(w, "wrong password", http.StatusForbidden)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	srn := r.URL.Query().Get("srn")
-	var name string
-	err = db.Raw("SELECT name FROM student WHERE student_id = ?", srn).Row().Scan(&name)
-
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+	if !found {
+		http.Error(w, "Student not found", http.StatusNotFound)
 		return
 	}
-	fmt.Printf("Student Name: %s\n", name)
-	fmt.Fprintf(w, "%s", name)
+	fmt.Fprintf(w, "%s", student.Name)
 }
 
-func GetStudentGithub(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+func GetStudentGithub(store Store, w http.ResponseWriter, r *http.Request) {
 	srn := r.URL.Query().Get("srn")
-
-	type RepoResult struct {
-		GithubID    string `json:"github_id"`
-		RepoID      string `json:"repo_id"`
-		RepoName    string `json:"repo_name"`
-		Language    string `json:"language"`
-		Description string `json:"description"`
+	repos, err := store.StudentRepositories(srn)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
-
-	var results []RepoResult
-	res := db.Raw(`
-		SELECT g.github_id, r.repo_id, r.repo_name, r.language, r.description
-		FROM github g
-		JOIN repository r ON g.github_id = r.github_id
-		WHERE g.student_id = ?`, srn).Scan(&results)
-
-	if res.Error != nil {
-		if res.Error == gorm.ErrRecordNotFound {
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+	if len(repos) == 0 {
+		http.Error(w, "No repositories found for this student", http.StatusNotFound)
 		return
 	}
 
-	if len(results) == 0 {
-		http.Error(w, "No repositories found for this student", http.StatusNotFound)
+	repoIDs := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		repoIDs = append(repoIDs, repo.RepoID)
+	}
+	repoLanguages, err := store.RepoLanguages(repoIDs)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+	languagesByRepo := make(map[string][]RepoLanguage)
+	for _, lang := range repoLanguages {
+		languagesByRepo[lang.RepoID] = append(languagesByRepo[lang.RepoID], lang)
+	}
 
+	type repoResult struct {
+		RepoID      string         `json:"repo_id"`
+		RepoName    string         `json:"repo_name"`
+		Language    string         `json:"language"`
+		Languages   []RepoLanguage `json:"languages"`
+		Description string         `json:"description"`
+	}
 	response := struct {
-		GithubID     string `json:"github_id"`
-		Repositories []struct {
-			RepoID      string `json:"repo_id"`
-			RepoName    string `json:"repo_name"`
-			Language    string `json:"language"`
-			Description string `json:"description"`
-		} `json:"repositories"`
+		GithubID     string       `json:"github_id"`
+		Repositories []repoResult `json:"repositories"`
 	}{
-		GithubID: results[0].GithubID,
-	}
-
-	fmt.Printf("results fetched for githubID: %s", response.GithubID)
-
-	for _, result := range results {
-		response.Repositories = append(response.Repositories, struct {
-			RepoID      string `json:"repo_id"`
-			RepoName    string `json:"repo_name"`
-			Language    string `json:"language"`
-			Description string `json:"description"`
-		}{
-			RepoID:      result.RepoID,
-			RepoName:    result.RepoName,
-			Language:    result.Language,
-			Description: result.Description,
+		GithubID: repos[0].GithubID,
+	}
+	for _, repo := range repos {
+		languages := languagesByRepo[repo.RepoID]
+		if languages == nil {
+			languages = []RepoLanguage{}
+		}
+		response.Repositories = append(response.Repositories, repoResult{
+			RepoID:      repo.RepoID,
+			RepoName:    repo.RepoName,
+			Language:    repo.Language,
+			Languages:   languages,
+			Description: repo.Desc,
 		})
 	}
 
@@ -502,32 +378,17 @@ func GetStudentGithub(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func GetLeetcode(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+func GetLeetcode(store Store, w http.ResponseWriter, r *http.Request) {
 	srn := r.URL.Query().Get("srn")
-	type LeetcodeResult struct {
-		LeetcodeID string `json:"leetcode_id"`
-		Ranking    int    `json:"ranking"`
-		NoEasy     int    `json:"no_easy"`
-		NoMedium   int    `json:"no_medium"`
-		NoHard     int    `json:"no_hard"`
+	leetcode, problems, found, err := store.StudentLeetCode(srn)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
 	}
-
-	var result LeetcodeResult
-	res := db.Raw(`
-		SELECT l.leetcode_id, l.ranking, p.no_easy, p.no_medium, p.no_hard
-		FROM leetcode l
-		JOIN problems p ON l.leetcode_id = p.leetcode_id
-		WHERE l.student_id = ?
-	`, srn).Scan(&result)
-	if res.Error != nil {
-		if res.Error == gorm.ErrRecordNotFound {
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
+	if !found {
+		http.Error(w, "No LeetCode profile found for this student", http.StatusNotFound)
 		return
 	}
-	totalSolved := result.NoEasy + result.NoMedium + result.NoHard
 	response := struct {
 		LeetcodeID   string `json:"leetcode_id"`
 		Ranking      int    `json:"ranking"`
@@ -536,12 +397,12 @@ func GetLeetcode(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		HardSolved   int    `json:"hard_solved"`
 		TotalSolved  int    `json:"total_solved"`
 	}{
-		LeetcodeID:   result.LeetcodeID,
-		Ranking:      result.Ranking,
-		EasySolved:   result.NoEasy,
-		MediumSolved: result.NoMedium,
-		HardSolved:   result.NoHard,
-		TotalSolved:  totalSolved,
+		LeetcodeID:   leetcode.LeetCodeID,
+		Ranking:      leetcode.Rank,
+		EasySolved:   problems.NoEasy,
+		MediumSolved: problems.NoMedium,
+		HardSolved:   problems.NoHard,
+		TotalSolved:  problems.NoEasy + problems.NoMedium + problems.NoHard,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -551,40 +412,7 @@ func GetLeetcode(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func GetResume(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
-	srn := r.URL.Query().Get("srn")
-	var path string
-	result := db.Raw("SELECT resume FROM student WHERE student_id = ?", srn).Scan(&path)
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			fmt.Println("Student not found")
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			fmt.Printf("Couldn't retrieve record: %v\n", result.Error)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-		}
-		return
-	}
-	fmt.Printf("Resume Path: %s\n", path)
-	// open the resume file
-	file, err := os.Open(path)
-	if err != nil {
-		fmt.Printf("Could not open file: %v\n", err)
-		http.Error(w, "File not found", http.StatusNotFound)
-		return
-	}
-	defer file.Close()
-	w.Header().Set("Content-Type", "application/pdf")
-	w.Header().Set("Content-Disposition", "inline; filename=resume.pdf")
-	_, err = io.Copy(w, file)
-	if err != nil {
-		fmt.Printf("Error while sending file: %v\n", err)
-		http.Error(w, "Error serving file", http.StatusInternalServerError)
-		return
-	}
-}
-
-func GetMentorSessions(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+func GetMentorSessions(store Store, w http.ResponseWriter, r *http.Request) {
 	type Session struct {
 		Date   string `json:"date"`
 		Advice string `json:"advice"`
@@ -601,21 +429,17 @@ func GetMentorSessions(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var sessions []Session
-
-	query := `
-		SELECT date, advice
-		FROM mentor_sessions
-		WHERE student_id = ?
-	`
-	if err := db.Raw(query, srn).Scan(&sessions).Error; err != nil {
+	stored, err := store.StudentSessions(srn)
+	if err != nil {
 		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-
 	response := MentorSessionsResponse{
 		SRN:      srn,
-		Sessions: sessions,
+		Sessions: make([]Session, 0, len(stored)),
+	}
+	for _, session := range stored {
+		response.Sessions = append(response.Sessions, Session{Date: session.Date, Advice: session.Advice})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -625,40 +449,48 @@ func GetMentorSessions(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func GetLinkedin(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+func GetLinkedin(store Store, w http.ResponseWriter, r *http.Request) {
 	srn := r.URL.Query().Get("srn")
 	if srn == "" {
 		http.Error(w, "Missing srn parameter", http.StatusBadRequest)
 		return
 	}
-	var linkedinLink string
-	err := db.Raw("SELECT linkedin FROM public.student WHERE student_id = ?", srn).Scan(&linkedinLink).Error
+	student, found, err := store.Student(srn)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to query database", http.StatusInternalServerError)
-		}
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Student not found", http.StatusNotFound)
 		return
 	}
-	if linkedinLink == "" {
+	if student.Linkedin == "" {
 		http.Error(w, "LinkedIn link not available", http.StatusNotFound)
 		return
 	}
 	response := map[string]string{
-		"linkedin": linkedinLink,
+		"linkedin": student.Linkedin,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
-func GetInfo(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+func GetInfo(store Store, w http.ResponseWriter, r *http.Request) {
 	srn := r.URL.Query().Get("srn")
 	if srn == "" {
 		http.Error(w, "Missing srn parameter", http.StatusBadRequest)
 		return
 	}
-	var studentInfo struct {
+	student, found, err := store.Student(srn)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Student not found", http.StatusNotFound)
+		return
+	}
+	studentInfo := struct {
 		SRN    string  `json:"srn"`
 		Gender string  `json:"gender"`
 		CGPA   float64 `json:"cgpa"`
@@ -667,610 +499,349 @@ func GetInfo(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
 		Degree string  `json:"degree"`
 		Stream string  `json:"stream"`
 		Age    int     `json:"age"`
-	}
-	err := db.Raw(`
-		SELECT student_id AS srn, gender, cgpa, email, sem, degree, stream, age
-		FROM
-			public.student
-		WHERE
-			student_id = ?`, srn).Scan(&studentInfo).Error
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Student not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to query database", http.StatusInternalServerError)
-		}
-		return
+	}{
+		SRN:    student.StudentID,
+		Gender: student.Gender,
+		CGPA:   student.CGPA,
+		Email:  student.Email,
+		Sem:    student.Sem,
+		Degree: student.Degree,
+		Stream: student.Stream,
+		Age:    student.Age,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(studentInfo)
 }
 
-func GetLeetCodeStatistics(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
-	type Statistics struct {
-		Srn  string  `json:"srn"`
-		Name string  `json:"name"`
-		Cgpa float64 `json:"cgpa"`
-		Sem  int     `json:"sem"`
-		Rank int     `json:"rank"`
-	}
+// publicKeyPath is the public_key_path of the config
+var publicKeyPath = defaultConfig().PublicKeyPath
 
-	srn := r.URL.Query().Get("srn")
-	if srn == "" {
-		http.Error(w, "Missing 'srn' query parameter", http.StatusBadRequest)
-		return
-	}
-
-	var stats []Statistics
-
-	var relativeRank int
+func servePublicKey(w http.ResponseWriter, r *http.Request) {
+	http.ServeFile(w, r, publicKeyPath)
+}
 
-	top15Query := `
-		SELECT s.student_id AS srn, s.name, s.cgpa, s.sem, l.ranking AS rank
-		FROM student s
-		JOIN leetcode l ON s.student_id = l.student_id
-		ORDER BY l.ranking
-		LIMIT 15
-	`
-	if err := db.Raw(top15Query).Scan(&stats).Error; err != nil {
-		http.Error(w, "Failed to retrieve top 15 data", http.StatusInternalServerError)
-		return
+// openDB opens and pings the postgres or sqlite database
+func openDB(cfg DatabaseConfig) (*gorm.DB, error) {
+	dialector := postgres.Open(cfg.DSN())
+	if cfg.Driver == "sqlite" {
+		dialector = sqlite.Open(cfg.DSN())
 	}
-	relativeRankQuery := `
-		SELECT COUNT(*) + 1 AS relative_rank
-		FROM leetcode l
-		WHERE l.ranking < (SELECT ranking FROM leetcode WHERE student_id = ?)
-	`
-
-	if err := db.Raw(relativeRankQuery, srn).Scan(&relativeRank).Error; err != nil {
-		http.Error(w, "Failed to retrieve relative rank", http.StatusInternalServerError)
-		return
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database: %v", err)
 	}
-	response := map[string]interface{}{
-		"leaderboard":   stats,
-		"relative_rank": relativeRank,
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %v", err)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response to JSON", http.StatusInternalServerError)
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
+	return db, nil
 }
 
-func GetCGPAStatistics(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
-	type Statistics struct {
-		Srn  string  `json:"srn"`
-		Name string  `json:"name"`
-		Cgpa float64 `json:"cgpa"`
-		Sem  int     `json:"sem"`
-		Rank int     `json:"rank"`
+// connectDB is openDB for the commands, which stop when it fails
+func connectDB(cfg DatabaseConfig) *gorm.DB {
+	db, err := openDB(cfg)
+	if err != nil {
+		log.Fatal(err)
 	}
+	fmt.Println("Successfully connected to the database!")
+	return db
+}
 
-	srn := r.URL.Query().Get("srn")
-	if srn == "" {
-		http.Error(w, "Missing 'srn' query parameter", http.StatusBadRequest)
-		return
+// applyConfig sets up the clients, providers and stores the config names
+func applyConfig(cfg Config) error {
+	githubClient = newGithubAPIClient(cfg.GithubToken)
+	provider, err := newLeetCodeProvider(cfg.LeetCode)
+	if err != nil {
+		return err
 	}
-	var stats []Statistics
-	var relativeRank int
-	top15Query := `
-		SELECT s.student_id AS srn, s.name, s.cgpa, s.sem, l.ranking AS rank
-		FROM student s
-		JOIN leetcode l ON s.student_id = l.student_id
-		ORDER BY s.cgpa DESC
-		LIMIT 15
-	`
-	if err := db.Raw(top15Query).Scan(&stats).Error; err != nil {
-		http.Error(w, "Failed to retrieve top 15 data", http.StatusInternalServerError)
-		return
+	leetcodeProvider = provider
+	if chatProvider, err = newChatProvider(cfg.Chat); err != nil {
+		return err
 	}
-	relativeRankQuery := `
-		SELECT COUNT(*) + 1 AS relative_rank
-		FROM student
-		WHERE cgpa > (SELECT cgpa FROM student WHERE student_id = ?)
-	`
-	if err := db.Raw(relativeRankQuery, srn).Scan(&relativeRank).Error; err != nil {
-		http.Error(w, "Failed to retrieve relative rank", http.StatusInternalServerError)
-		return
-	}
-	response := map[string]interface{}{
-		"leaderboard":   stats,
-		"relative_rank": relativeRank,
+	resumeStore = newLocalFileStore(cfg.ResumeDir)
+	publicKeyPath = cfg.PublicKeyPath
+	deletionRetention = time.Duration(cfg.DeleteRetention)
+	if skillDictionary, err = loadSkillDictionary(cfg.SkillsPath); err != nil {
+		return fmt.Errorf("could not load skill dictionary: %v", err)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "Failed to encode response to JSON", http.StatusInternalServerError)
+	if scoreConfig, err = loadScoreConfig(cfg.ScoreWeightsPath); err != nil {
+		return fmt.Errorf("could not load score weights: %v", err)
 	}
+	return nil
 }
 
-func deleteStudent(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
-	srn := r.URL.Query().Get("srn")
-	if err := db.Exec("DELETE FROM student WHERE student_id = ?", srn).Error; err != nil {
-		http.Error(w, "Failed to delete student", http.StatusInternalServerError)
-		return
+func main() {
+	cfg, err := loadConfig(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := applyConfig(cfg); err != nil {
+		log.Fatal(err)
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Student and all associated records deleted successfully"))
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			importCommand(cfg, os.Args[2:])
+			return
+		case "passwd":
+			passwdCommand(cfg, os.Args[2:])
+			return
+		case "reindex-resumes":
+			reindexCommand(cfg, os.Args[2:])
+			return
+		case "migrate":
+			migrateCommand(cfg, os.Args[2:])
+			return
+		case "serve":
+		default:
+			fmt.Fprintf(os.Stderr, "usage: %s [serve | import [-data file] [-mentors file] | passwd (-srn SRN | -mentor ID) | reindex-resumes | migrate (up | down | status)]\n", os.Args[0])
+			os.Exit(2)
+		}
+	}
+	serve(cfg)
 }
 
-func servePublicKey(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "/home/suraj/Documents/keys/public_key.pem")
-}
+// registerStoreRoutes adds the routes whose handlers only need a Store, so
+// the handler tests can serve them from a memoryStore
+func registerStoreRoutes(r *mux.Router, store Store) {
+	r.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		StudentLogin(store, w, r)
+	}).Methods("POST")
 
-var counter int = 0
+	r.HandleFunc("/mentorLogin", func(w http.ResponseWriter, r *http.Request) {
+		MentorLogin(store, w, r)
+	}).Methods("POST")
 
-func InsertStudent(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	srn := r.URL.Query().Get("srn")
-	sem := r.URL.Query().Get("sem")
-	git_link := r.URL.Query().Get("git_link")
-	leet_link := r.URL.Query().Get("leet_link")
-	men_name := r.URL.Query().Get("men_name")
-	linkedin_link := r.URL.Query().Get("linkedin_link")
-	cgpa := r.URL.Query().Get("cgpa")
-	age := r.URL.Query().Get("age")
-	phone_num := r.URL.Query().Get("phone_num")
-	degree := r.URL.Query().Get("degree")
-	stream := r.URL.Query().Get("stream")
-	gender := r.URL.Query().Get("gender")
-	email := r.URL.Query().Get("email")
-	resume := r.URL.Query().Get("resume")
-
-	fmt.Printf("Name: %s\nSRN: %s\nSemester: %s\nGitHub Link: %s\nLeetCode Link: %s\nMentor Name: %s\nLinkedIn Link: %s\nCGPA: %s\nAge: %s\nPhone Number: %s\nDegree: %s\nStream: %s\nGender: %s\nEmail: %s\nResume Link: %s\n",
-		name, srn, sem, git_link, leet_link, men_name, linkedin_link, cgpa, age, phone_num, degree, stream, gender, email, resume)
-
-	username, err := getUsernameFromURL(git_link)
-	token := ""
-	profile := fetchProfileData(username, token)
-	git := Github{
-		GithubID:  git_link,
-		StudentID: srn,
-		Username:  profile.Username,
-		Bio:       profile.Bio,
-		RepoCount: profile.RepoCount,
-	}
+	r.HandleFunc("/mentor/students", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetMentorStudents(store, w, r)
+	})).Methods("GET")
 
-	mentorid, err := fetchMentorID(db, men_name)
-	summ := srn + "_Resume.pdf"
-	cmd := exec.Command("gdown", "--fuzzy", resume, "-O", summ)
+	r.HandleFunc("/mentor/sessions", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListMentorSessions(store, w, r)
+	})).Methods("GET")
 
-	cmd.Dir = "/home/suraj/Documents/Resumes"
+	r.HandleFunc("/mentor/sessions", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		CreateMentorSession(store, w, r)
+	})).Methods("POST")
 
-	output, err := cmd.Output()
-	if err != nil {
-		http.Error(w, "Couldn't add resume", http.StatusInternalServerError)
-		log.Printf("Error executing command: %v", err)
-	}
-	fmt.Println(string(output))
-
-	resumePath := filepath.Join("/home/suraj/Documents/Resumes", srn+"_Resume.pdf")
-	cGpa, err := strconv.ParseFloat(cgpa, 64)
-	aGe, err := strconv.Atoi(age)
-	sEm, err := strconv.Atoi(sem)
-
-	student := Student{
-		StudentID: srn,
-		Name:      name,
-		PhoneNo:   phone_num,
-		Dob:       time.Now(),
-		Gender:    gender,
-		Resume:    resumePath,
-		Sem:       sEm,
-		MentorID:  mentorid,
-		CGPA:      cGpa,
-		Email:     email,
-		Age:       aGe,
-		Linkedin:  linkedin_link,
-		Degree:    degree,
-		Stream:    stream,
-	}
+	r.HandleFunc("/mentor/sessions/{id}", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		UpdateMentorSession(store, w, r)
+	})).Methods("PUT")
 
-	err = insertStudent(db, student)
-	if err != nil {
-		http.Error(w, "Couldn't insert student", http.StatusInternalServerError)
-		log.Printf("failed to insert student:", err)
-	}
-	log.Println("Student inserted successfully")
+	r.HandleFunc("/mentor/sessions/{id}", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		DeleteMentorSession(store, w, r)
+	})).Methods("DELETE")
 
-	err = insertGithub(db, git)
-	if err != nil {
-		http.Error(w, "Couldn't insert github", http.StatusInternalServerError)
-		log.Print("failed to insert github:", err)
-	}
-	log.Println("github inserted successfully")
-	//inserting repos
-	fmt.Printf("Username: %s\n", profile.Username)
-	fmt.Printf("Bio: %s\n", profile.Bio)
-	fmt.Printf("Public Repositories: %s\n", profile.RepoCount)
-	fmt.Println("Pinned Repositories:")
-	for _, repo := range profile.PinnedRepos {
-		fmt.Printf("Repository Name: %s\n", repo.Name)
-		fmt.Printf("About: %s\n", repo.About)
-		fmt.Printf("Languages Used: %s\n", strings.Join(repo.Languages, ", "))
-		test_repo := Repository{
-			RepoID:   git_link + "/" + repo.Name,
-			GithubID: git_link,
-			RepoName: repo.Name,
-			Language: strings.Join(repo.Languages, ", "),
-			Desc:     repo.About,
-		}
-		fmt.Printf("testing repository struct repo:%s, git:%s, reponame:%s, lang:%s, desc:%s\n", test_repo.RepoID, test_repo.GithubID, test_repo.RepoName, test_repo.Language, test_repo.Desc)
-		if err := insertRepository(db, test_repo); err != nil {
-			http.Error(w, "Couldnt insert repo", http.StatusInternalServerError)
-			log.Fatalf("Failed to insert repository: %v", err)
-		} else {
-			log.Println("Repository inserted successfully")
-		}
-	}
-	//inserting leetcode
-	if username, err := getUsernameFromURL(leet_link); err == nil {
-		leetProfile := fetchLeetCodeProfileData(username)
-		fmt.Printf("LeetCode Username: %s, Total Solved: %d, Easy: %d, Medium: %d, Hard: %d, Rank: %d\n",
-			leetProfile.Username, leetProfile.TotalSolved, leetProfile.EasySolved, leetProfile.MediumSolved, leetProfile.HardSolved, leetProfile.Ranking)
-		leetcodeData := LeetCode{
-			LeetCodeID: leet_link,
-			StudentID:  srn,
-			Username:   leetProfile.Username,
-			Rank:       leetProfile.Ranking,
-		}
+	r.HandleFunc("/student", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetStudentName(store, w, r)
+	})).Methods("GET", "POST")
 
-		err = insertLeetCode(db, leetcodeData)
-		if err != nil {
-			http.Error(w, "Failed to insert leetcode", http.StatusInternalServerError)
-			log.Printf("Failed to insert LeetCode data for %s: %v", leet_link, err)
-		} else {
-			log.Println("LeetCode data inserted successfully")
-		}
-	} else {
-		log.Printf("Skipping invalid LeetCode URL for student %s: %v", srn, err)
-	}
+	r.HandleFunc("/getGithub", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetStudentGithub(store, w, r)
+	})).Methods("GET")
 
-	if userleet, err := getUsernameFromURL(leet_link); err == nil {
-		leetProfile := fetchLeetCodeProfileData(userleet)
-		url := fmt.Sprintf("https://leetcode.com/%s", userleet)
-		problems := Problems{
-			ProblemID:  counter,
-			LeetcodeID: url,
-			NoEasy:     leetProfile.EasySolved,
-			NoMedium:   leetProfile.MediumSolved,
-			NoHard:     leetProfile.HardSolved,
-		}
-		err = insertProblems(db, problems)
-		if err != nil {
-			http.Error(w, "Failed to insert problems", http.StatusInternalServerError)
-			log.Println("failed to insert problems", err)
-		}
-		log.Println("problems inserted successfully")
-		counter = counter + 1
-	}
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Student and all associated records inserted successfully"))
+	r.HandleFunc("/getLeetcode", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetLeetcode(store, w, r)
+	})).Methods("GET")
 
+	r.HandleFunc("/getMentorSessions", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetMentorSessions(store, w, r)
+	})).Methods("GET")
+
+	r.HandleFunc("/getLinkedin", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetLinkedin(store, w, r)
+	})).Methods("GET")
+
+	r.HandleFunc("/getInfo", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetInfo(store, w, r)
+	})).Methods("GET")
 }
 
-func main() {
+// serve starts the HTTP API. Pending migrations are applied first; the data
+// itself is expected to be seeded already, see the import command.
+func serve(cfg Config) {
 	start := time.Now()
+	db := connectDB(cfg.Database)
+	initSessionSecret(cfg.SessionSecret)
+	migrateDatabase(db)
+	startRefreshScheduler(context.Background(), db, cfg.refreshConfig())
+	startDeletionPurger(context.Background(), db)
 
-	dsn := "host=100.102.21.101 user=postgres password=dbms_porj dbname=dbms_project port=5432 sslmode=disable TimeZone=Asia/Shanghai"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	if err != nil {
-		panic("failed to connect database")
-	}
-	sqlDB, err := db.DB()
-	if err != nil {
-		panic("failed to get database handle")
-	}
-	err = sqlDB.Ping()
-	if err != nil {
-		panic("failed to ping database")
-	}
+	r := mux.NewRouter()
+	registerStoreRoutes(r, newSQLStore(db))
 
-	fmt.Println("Successfully connected to the database!")
+	r.HandleFunc("/score", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetScore(db, w, r)
+	})).Methods("GET")
 
-	//start of concurrency
-	infoChan := make(chan []Info)
-	mentorInfoChan := make(chan []Mentor_Session_CSV)
-	errorChan := make(chan error)
+	r.HandleFunc("/refreshStatus", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetRefreshStatus(db, w, r)
+	})).Methods("GET")
 
-	var wg sync.WaitGroup
-	wg.Add(2)
+	r.HandleFunc("/getLanguageProfile", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetLanguageProfile(db, w, r)
+	})).Methods("GET")
 
-	go func() {
-		defer wg.Done()
-		file, err := os.Open("data.csv")
-		if err != nil {
-			errorChan <- fmt.Errorf("could not open CSV file: %v", err)
-			return
-		}
-		defer file.Close()
+	r.HandleFunc("/students/byLanguage", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		FindStudentsByLanguage(db, w, r)
+	})).Methods("GET")
 
-		reader := csv.NewReader(file)
-		decoder, err := csvutil.NewDecoder(reader)
-		if err != nil {
-			errorChan <- fmt.Errorf("could not create CSV decoder: %v", err)
-			return
-		}
+	r.HandleFunc("/getLeetcodeHistory", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetLeetcodeHistory(db, w, r)
+	})).Methods("GET")
 
-		var all_info []Info
-		for {
-			var sing_info Info
-			if err := decoder.Decode(&sing_info); err != nil {
-				if err == io.EOF {
-					break
-				}
-				errorChan <- fmt.Errorf("could not decode record: %v", err)
-				return
-			}
-			all_info = append(all_info, sing_info)
-		}
-		infoChan <- all_info
-	}()
-
-	go func() {
-		defer wg.Done()
-		mentor_file, err := os.Open("mentor_sesh.csv")
-		if err != nil {
-			errorChan <- fmt.Errorf("failed to open mentor csv file: %v", err)
-			return
-		}
-		defer mentor_file.Close()
+	r.HandleFunc("/getResume", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetResume(db, w, r)
+	})).Methods("GET")
 
-		mentor_reader := csv.NewReader(mentor_file)
-		mentor_decoder, err := csvutil.NewDecoder(mentor_reader)
-		if err != nil {
-			errorChan <- fmt.Errorf("couldn't make mentor decoder: %v", err)
-			return
-		}
+	r.HandleFunc("/leaderboard", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetLeaderboard(db, w, r)
+	})).Methods("GET")
 
-		var mentor_info []Mentor_Session_CSV
-		for {
-			var men_info Mentor_Session_CSV
-			if err := mentor_decoder.Decode(&men_info); err != nil {
-				if err == io.EOF {
-					break
-				}
-				errorChan <- fmt.Errorf("couldn't decode mentor record: %v", err)
-				return
-			}
-			mentor_info = append(mentor_info, men_info)
-		}
-		mentorInfoChan <- mentor_info
-	}()
-
-	go func() {
-		wg.Wait()
-		close(infoChan)
-		close(mentorInfoChan)
-		close(errorChan)
-	}()
-
-	go func() {
-		for err := range errorChan {
-			log.Fatal(err)
-		}
-	}()
+	r.HandleFunc("/students", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		CreateStudent(db, w, r)
+	})).Methods("POST")
 
-	var all_info []Info
-	var mentor_info []Mentor_Session_CSV
+	r.HandleFunc("/students/{srn}", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		UpdateStudent(db, w, r)
+	})).Methods("PATCH")
 
-	for i := 0; i < 2; i++ {
-		select {
-		case info := <-infoChan:
-			all_info = info
-		case mentorInfo := <-mentorInfoChan:
-			mentor_info = mentorInfo
-		}
-	}
+	r.HandleFunc("/students/{srn}", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		DeleteStudent(db, w, r)
+	})).Methods("DELETE")
 
-	for _, student := range all_info {
-		fmt.Printf("Name: %s, SRN: %s, CGPA: %.2f, Sem: %d, Email: %s, Phone: %s, Degree: %s, Stream: %s, Gender: %s, GitHub: %s, LeetCode: %s, Mentor: %s, Resume: %s\n",
-			student.Name, student.StudentID, student.CGPA, student.Sem, student.Email, student.PhoneNo,
-			student.Degree, student.Stream, student.Gender, student.GithubProfile,
-			student.LeetcodeProfile, student.MentorID, student.Resume, student.Linkedin)
+	r.HandleFunc("/students/{srn}/resume", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		UploadResume(db, w, r)
+	})).Methods("POST")
 
-		mentorID, err := fetchMentorID(db, student.MentorID)
-		if err != nil {
-			log.Printf("Could not find mentor ID for %s: %v", student.MentorID, err)
-			continue
-		}
+	r.HandleFunc("/students/{srn}/resume/versions", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListResumeVersions(db, w, r)
+	})).Methods("GET")
 
-		username_np := student.GithubProfile
-		token := ""
-		username, err := getUsernameFromURL(username_np)
-		profile := fetchProfileData(username, token)
-		github := Github{
-			GithubID:  student.GithubProfile,
-			StudentID: student.StudentID,
-			Username:  profile.Username,
-			Bio:       profile.Bio,
-			RepoCount: profile.RepoCount,
-		}
-		git_prof := student.GithubProfile
-		log.Println(mentorID)
-
-		student := Student{
-			StudentID: student.StudentID,
-			Name:      student.Name,
-			PhoneNo:   student.PhoneNo,
-			Dob:       student.DOB,
-			Gender:    student.Gender,
-			Resume:    student.Resume,
-			Sem:       student.Sem,
-			MentorID:  mentorID,
-			CGPA:      student.CGPA,
-			Email:     student.Email,
-			Age:       student.Age,
-			Linkedin:  student.Linkedin,
-			Degree:    student.Degree,
-			Stream:    student.Stream,
-		}
+	r.HandleFunc("/students/{srn}/resume/versions/{version}", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetResumeVersion(db, w, r)
+	})).Methods("GET")
 
-		resumePath := filepath.Join("/home/suraj/Documents/Resumes", student.Resume)
-		student.Resume = resumePath
+	r.HandleFunc("/students/{srn}/resume/current", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		SetCurrentResume(db, w, r)
+	})).Methods("PUT")
 
-		err = insertStudent(db, student)
-		if err != nil {
-			log.Fatal("failed to insert student:", err)
-		}
-		log.Println("Student inserted successfully")
+	r.HandleFunc("/getResumeProfile", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetResumeProfile(db, w, r)
+	})).Methods("GET")
 
-		err = insertGithub(db, github)
-		if err != nil {
-			log.Fatal("failed to insert github:", err)
-		}
-		log.Println("github inserted successfully")
-
-		fmt.Printf("Username: %s\n", profile.Username)
-		fmt.Printf("Bio: %s\n", profile.Bio)
-		fmt.Printf("Public Repositories: %s\n", profile.RepoCount)
-		fmt.Println("Pinned Repositories:")
-
-		for _, repo := range profile.PinnedRepos {
-			fmt.Printf("Repository Name: %s\n", repo.Name)
-			fmt.Printf("About: %s\n", repo.About)
-			fmt.Printf("Languages Used: %s\n", strings.Join(repo.Languages, ", "))
-			test_repo := Repository{
-				RepoID:   git_prof + "/" + repo.Name,
-				GithubID: git_prof,
-				RepoName: repo.Name,
-				Language: strings.Join(repo.Languages, ", "),
-				Desc:     repo.About,
-			}
-			fmt.Printf("testing repository struct repo:%s, git:%s, reponame:%s, lang:%s, desc:%s\n", test_repo.RepoID, test_repo.GithubID, test_repo.RepoName, test_repo.Language, test_repo.Desc)
-			if err := insertRepository(db, test_repo); err != nil {
-				log.Fatalf("Failed to insert repository: %v", err)
-			} else {
-				log.Println("Repository inserted successfully")
-			}
-		}
-	}
+	r.HandleFunc("/students/byResumeSkill", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		FindStudentsByResumeSkill(db, w, r)
+	})).Methods("GET")
 
-	for _, student := range all_info {
-		if username, err := getUsernameFromURL(student.LeetcodeProfile); err == nil {
-			leetProfile := fetchLeetCodeProfileData(username)
-			fmt.Printf("LeetCode Username: %s, Total Solved: %d, Easy: %d, Medium: %d, Hard: %d, Rank: %d\n",
-				leetProfile.Username, leetProfile.TotalSolved, leetProfile.EasySolved, leetProfile.MediumSolved, leetProfile.HardSolved, leetProfile.Ranking)
-
-			leetcodeData := LeetCode{
-				LeetCodeID: student.LeetcodeProfile,
-				StudentID:  student.StudentID,
-				Username:   leetProfile.Username,
-				Rank:       leetProfile.Ranking,
-			}
-
-			err = insertLeetCode(db, leetcodeData)
-			if err != nil {
-				log.Printf("Failed to insert LeetCode data for %s: %v", student.LeetcodeProfile, err)
-			} else {
-				log.Println("LeetCode data inserted successfully")
-			}
-		} else {
-			log.Printf("Skipping invalid LeetCode URL for student %s: %v", student.Name, err)
-		}
+	r.HandleFunc("/students/{srn}/restore", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		RestoreStudent(db, w, r)
+	})).Methods("POST")
 
-		if userleet, err := getUsernameFromURL(student.LeetcodeProfile); err == nil {
-			leetProfile := fetchLeetCodeProfileData(userleet)
-			url := fmt.Sprintf("https://leetcode.com/%s", userleet)
-			problems := Problems{
-				ProblemID:  counter,
-				LeetcodeID: url,
-				NoEasy:     leetProfile.EasySolved,
-				NoMedium:   leetProfile.MediumSolved,
-				NoHard:     leetProfile.HardSolved,
-			}
-			err = insertProblems(db, problems)
-			if err != nil {
-				log.Fatal("failed to insert problems", err)
-			}
-			log.Println("problems inserted successfully")
-			counter = counter + 1
-		}
-	}
+	r.HandleFunc("/students/{srn}/audit", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetStudentAudit(db, w, r)
+	})).Methods("GET")
 
-	for _, m_info := range mentor_info {
-		m_id, err := fetchMentorID(db, m_info.MentorID)
-		if err != nil {
-			break
-		}
-		fmt.Printf("mentor_id: %d stud_id: %s", m_id, m_info.StudentID)
-		m_str := Mentor_Session_DB{
-			MentorID:  m_id,
-			StudentID: m_info.StudentID,
-			Date:      m_info.Date,
-			Advice:    m_info.Advice,
-		}
-		fmt.Printf("advice: %s\n", m_str.Advice)
-		err = insertMentorSessions(db, m_str)
-		if err != nil {
-			log.Fatal("failed to insert mentor sessions", err)
-		}
-		log.Println("mentor sessions inserted successfully")
-	}
+	r.HandleFunc("/mentor/deletedStudents", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListDeletedStudents(db, w, r)
+	})).Methods("GET")
 
-	fmt.Printf("!!!done setting up database!!!")
+	r.HandleFunc("/companies", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListCompanies(db, w, r)
+	})).Methods("GET")
 
-	r := mux.NewRouter()
+	r.HandleFunc("/companies", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		CreateCompany(db, w, r)
+	})).Methods("POST")
 
-	r.HandleFunc("/student", func(w http.ResponseWriter, r *http.Request) {
-		GetStudentName(db, w, r)
-	}).Methods("GET", "POST")
+	r.HandleFunc("/companies/{company_id}", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetCompany(db, w, r)
+	})).Methods("GET")
 
-	r.HandleFunc("/getGithub", func(w http.ResponseWriter, r *http.Request) {
-		GetStudentGithub(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/companies/{company_id}", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		UpdateCompany(db, w, r)
+	})).Methods("PUT")
 
-	r.HandleFunc("/getLeetcode", func(w http.ResponseWriter, r *http.Request) {
-		GetLeetcode(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/companies/{company_id}", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		DeleteCompany(db, w, r)
+	})).Methods("DELETE")
 
-	r.HandleFunc("/getResume", func(w http.ResponseWriter, r *http.Request) {
-		GetResume(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/drives", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListDrives(db, w, r)
+	})).Methods("GET")
+
+	r.HandleFunc("/drives", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		CreateDrive(db, w, r)
+	})).Methods("POST")
+
+	r.HandleFunc("/drives/{drive_id}", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetDrive(db, w, r)
+	})).Methods("GET")
+
+	r.HandleFunc("/drives/{drive_id}", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		UpdateDrive(db, w, r)
+	})).Methods("PUT")
+
+	r.HandleFunc("/drives/{drive_id}", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		DeleteDrive(db, w, r)
+	})).Methods("DELETE")
+
+	r.HandleFunc("/drives/{drive_id}/eligible", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListEligibleStudents(db, w, r)
+	})).Methods("GET")
+
+	r.HandleFunc("/drives/{drive_id}/eligibility", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		GetDriveEligibility(db, w, r)
+	})).Methods("GET")
 
-	r.HandleFunc("/getMentorSessions", func(w http.ResponseWriter, r *http.Request) {
-		GetMentorSessions(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/drives/{drive_id}/applications", requireStudent(func(w http.ResponseWriter, r *http.Request) {
+		ApplyToDrive(db, w, r)
+	})).Methods("POST")
 
-	r.HandleFunc("/getLinkedin", func(w http.ResponseWriter, r *http.Request) {
-		GetLinkedin(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/drives/{drive_id}/applications", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListDriveApplications(db, w, r)
+	})).Methods("GET")
 
-	r.HandleFunc("/getInfo", func(w http.ResponseWriter, r *http.Request) {
-		GetInfo(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/students/{srn}/applications", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		ListStudentApplications(db, w, r)
+	})).Methods("GET")
 
-	r.HandleFunc("/getLeetCodeStatistics", func(w http.ResponseWriter, r *http.Request) {
-		GetLeetCodeStatistics(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/applications/{application_id}/stage", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		MoveApplication(db, w, r)
+	})).Methods("POST")
 
-	r.HandleFunc("/getCGPAStatistics", func(w http.ResponseWriter, r *http.Request) {
-		GetCGPAStatistics(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/placementStatistics", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetPlacementStatistics(db, w, r)
+	})).Methods("GET")
 
-	r.HandleFunc("/deleteStudent", func(w http.ResponseWriter, r *http.Request) {
-		deleteStudent(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/students/{srn}/chat", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		Chat(db, w, r)
+	})).Methods("POST")
 
-	// r.HandleFunc("/getPublicKey", servePublicKey).Methods("GET")
+	r.HandleFunc("/students/{srn}/chat", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetChatHistory(db, w, r)
+	})).Methods("GET")
 
-	r.HandleFunc("/insertStudent", func(w http.ResponseWriter, r *http.Request) {
-		InsertStudent(db, w, r)
-	}).Methods("GET")
+	r.HandleFunc("/students/{srn}/chat", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		ClearChatHistory(db, w, r)
+	})).Methods("DELETE")
 
 	elapsed := time.Since(start)
 	fmt.Printf("\nElapsed Time: %s\n", elapsed)
 
 	corsHandler := handlers.CORS(
-		handlers.AllowedOrigins([]string{"*"}),
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		handlers.AllowedOrigins(cfg.AllowedOrigins),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
 		handlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Encrypted-AES-Key"}),
 		handlers.AllowCredentials(),
 	)(r)
 
-	// start the server on port 8000
-	fmt.Println("Server is running on port 8000")
-	http.ListenAndServe(":8000", corsHandler)
+	fmt.Printf("Server is listening on %s\n", cfg.Listen)
+	log.Fatal(http.ListenAndServe(cfg.Listen, corsHandler))
 }
 
 //testing.....
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// MentorCredential stores the bcrypt hash of a mentor's password
+type MentorCredential struct {
+	MentorID     int       `gorm:"primaryKey;column:mentor_id"`
+	PasswordHash string    `gorm:"column:password_hash"`
+	UpdatedAt    time.Time `gorm:"column:updated_at"`
+}
+
+func (MentorCredential) TableName() string {
+	return "mentor_credentials"
+}
+
+const roleMentor = "mentor"
+
+// requireMentor only lets a logged in mentor through
+func requireMentor(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, status, err := authenticate(r, roleMentor)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, s)))
+	}
+}
+
+// mentorIDFromContext returns the mentor of the session attached by requireMentor
+func mentorIDFromContext(ctx context.Context) (int, bool) {
+	s, ok := sessionFromContext(ctx)
+	if !ok || s.Role != roleMentor {
+		return 0, false
+	}
+	id, err := strconv.Atoi(s.Subject)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// MentorLogin checks the mentor ID and password sent as a JSON body and issues
+// a mentor session
+func MentorLogin(store Store, w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		MentorID string `json:"mentorId"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	mentorID, err := strconv.Atoi(strings.TrimSpace(creds.MentorID))
+	if err != nil {
+		http.Error(w, "Invalid mentor ID or password", http.StatusUnauthorized)
+		return
+	}
+
+	cred, found, err := store.MentorCredential(mentorID)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	hash := []byte(cred.PasswordHash)
+	if !found {
+		hash = dummyHash
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(creds.Password)); err != nil || !found {
+		http.Error(w, "Invalid mentor ID or password", http.StatusUnauthorized)
+		return
+	}
+
+	expires := time.Now().Add(sessionTTL)
+	token, err := signSession(Session{Subject: strconv.Itoa(mentorID), Role: roleMentor, Expires: expires.Unix()})
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	mentor, _, _ := store.Mentor(mentorID)
+
+	response := map[string]interface{}{
+		"token":       token,
+		"mentor_id":   mentorID,
+		"mentor_name": mentor.Name,
+		"expires_at":  expires.UTC().Format(time.RFC3339),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetMentorStudents lists the students assigned to the logged in mentor
+func GetMentorStudents(store Store, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return
+	}
+
+	type MentorStudent struct {
+		SRN    string  `json:"srn"`
+		Name   string  `json:"name"`
+		Sem    int     `json:"sem"`
+		CGPA   float64 `json:"cgpa"`
+		Degree string  `json:"degree"`
+		Stream string  `json:"stream"`
+		Email  string  `json:"email"`
+	}
+
+	stored, err := store.MentorStudents(mentorID)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	students := make([]MentorStudent, 0, len(stored))
+	for _, s := range stored {
+		students = append(students, MentorStudent{
+			SRN:    s.StudentID,
+			Name:   s.Name,
+			Sem:    s.Sem,
+			CGPA:   s.CGPA,
+			Degree: s.Degree,
+			Stream: s.Stream,
+			Email:  s.Email,
+		})
+	}
+
+	response := map[string]interface{}{
+		"mentor_id": mentorID,
+		"students":  students,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// setMentorPassword stores a new bcrypt hash for the mentor
+func setMentorPassword(db *gorm.DB, mentorID int, password string) error {
+	if len(password) < 8 {
+		return errors.New("password must be at least 8 characters")
+	}
+	exists, err := rowExists(db, "SELECT 1 FROM mentor WHERE mentor_id = ?", mentorID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("mentor %d not found", mentorID)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return db.Exec(`
+		INSERT INTO mentor_credentials (mentor_id, password_hash, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (mentor_id) DO UPDATE SET password_hash = EXCLUDED.password_hash, updated_at = EXCLUDED.updated_at`,
+		mentorID, string(hash), time.Now()).Error
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const sessionDateLayout = "2006-01-02"
+
+// MentorSessionJSON is how a mentor session is returned by the API
+type MentorSessionJSON struct {
+	SessionID int64  `json:"session_id"`
+	SRN       string `json:"srn"`
+	MentorID  int    `json:"mentor_id"`
+	Date      string `json:"date"`
+	Advice    string `json:"advice"`
+}
+
+// parseSessionDate accepts YYYY-MM-DD dates that are not in the future
+func parseSessionDate(value string) (time.Time, error) {
+	date, err := time.Parse(sessionDateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	if date.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("date %s is in the future", value)
+	}
+	return date, nil
+}
+
+// mentorOwnsStudent reports whether the student is assigned to the mentor
+func mentorOwnsStudent(db *gorm.DB, mentorID int, srn string) (bool, error) {
+	return rowExists(db, "SELECT 1 FROM student WHERE student_id = ? AND mentor_id = ?", srn, mentorID)
+}
+
+// storeMentorOwnsStudent is mentorOwnsStudent for the handlers that use a Store
+func storeMentorOwnsStudent(store Store, mentorID int, srn string) (bool, error) {
+	student, found, err := store.Student(srn)
+	return found && student.MentorID == mentorID, err
+}
+
+// sessionForMentor loads the session in the URL and checks that it belongs to
+// one of the mentor's students. It writes the error response itself.
+func sessionForMentor(store Store, w http.ResponseWriter, r *http.Request) (MentorSessionJSON, bool) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return MentorSessionJSON{}, false
+	}
+	sessionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid session id", http.StatusBadRequest)
+		return MentorSessionJSON{}, false
+	}
+	session, found, err := store.MentorSession(sessionID)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return session, false
+	}
+	if !found {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return session, false
+	}
+	owns, err := storeMentorOwnsStudent(store, mentorID, session.SRN)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return session, false
+	}
+	if !owns {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return session, false
+	}
+	return session, true
+}
+
+func writeMentorSession(w http.ResponseWriter, status int, session MentorSessionJSON) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(session)
+}
+
+// ListMentorSessions lists the sessions of the mentor's students, optionally
+// only those of one srn
+func ListMentorSessions(store Store, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return
+	}
+	sessions, err := store.MentorSessions(mentorID, r.URL.Query().Get("srn"))
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"sessions": sessions})
+}
+
+// CreateMentorSession records a session for one of the mentor's students
+func CreateMentorSession(store Store, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return
+	}
+	var body struct {
+		SRN    string `json:"srn"`
+		Date   string `json:"date"`
+		Advice string `json:"advice"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	body.SRN = strings.ToUpper(strings.TrimSpace(body.SRN))
+	if body.SRN == "" {
+		http.Error(w, "Missing srn", http.StatusBadRequest)
+		return
+	}
+	date, err := parseSessionDate(body.Date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(body.Advice) == "" {
+		http.Error(w, "Missing advice", http.StatusBadRequest)
+		return
+	}
+	owns, err := storeMentorOwnsStudent(store, mentorID, body.SRN)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if !owns {
+		http.Error(w, "Student not found among your mentees", http.StatusForbidden)
+		return
+	}
+
+	session := MentorSessionJSON{
+		SRN:      body.SRN,
+		MentorID: mentorID,
+		Date:     date.Format(sessionDateLayout),
+		Advice:   body.Advice,
+	}
+	if session.SessionID, err = store.CreateMentorSession(session); err != nil {
+		http.Error(w, "couldn't insert into mentor_sessions", http.StatusInternalServerError)
+		return
+	}
+	writeMentorSession(w, http.StatusCreated, session)
+}
+
+// UpdateMentorSession changes the date and/or advice of a session
+func UpdateMentorSession(store Store, w http.ResponseWriter, r *http.Request) {
+	session, ok := sessionForMentor(store, w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Date   *string `json:"date"`
+		Advice *string `json:"advice"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.Date != nil {
+		date, err := parseSessionDate(*body.Date)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		session.Date = date.Format(sessionDateLayout)
+	}
+	if body.Advice != nil {
+		if strings.TrimSpace(*body.Advice) == "" {
+			http.Error(w, "Advice cannot be empty", http.StatusBadRequest)
+			return
+		}
+		session.Advice = *body.Advice
+	}
+
+	if err := store.UpdateMentorSession(session); err != nil {
+		http.Error(w, "couldn't update mentor_sessions", http.StatusInternalServerError)
+		return
+	}
+	writeMentorSession(w, http.StatusOK, session)
+}
+
+// DeleteMentorSession removes a session
+func DeleteMentorSession(store Store, w http.ResponseWriter, r *http.Request) {
+	session, ok := sessionForMentor(store, w, r)
+	if !ok {
+		return
+	}
+	deleted, err := store.DeleteMentorSession(session.SessionID)
+	if err != nil {
+		http.Error(w, "couldn't delete from mentor_sessions", http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
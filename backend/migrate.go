@@ -0,0 +1,282 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationFiles has one directory of migrations per dialect, postgres and
+// sqlite, with the same versions and names in both
+//
+//go:embed migrations/*/*.sql
+var migrationFiles embed.FS
+
+// migration is one numbered schema change with the SQL that applies it and
+// the SQL that reverts it
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// loadMigrations reads NNNN_name.up.sql and NNNN_name.down.sql pairs from
+// fsys. Versions have to start at 1 and have no gaps.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*migration{}
+	for _, entry := range entries {
+		m := migrationFileName.FindStringSubmatch(entry.Name())
+		if m == nil || entry.IsDir() {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(data)
+		} else {
+			mig.Down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, mig := range migrations {
+		if mig.Version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+	}
+	return migrations, nil
+}
+
+// embeddedMigrations are the migrations built into the binary for the
+// dialect of the database
+func embeddedMigrations(dialect string) ([]migration, error) {
+	sub, err := fs.Sub(migrationFiles, "migrations/"+dialect)
+	if err != nil {
+		return nil, err
+	}
+	return loadMigrations(sub)
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	Version   int       `gorm:"column:version"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func appliedMigrations(db *gorm.DB) (map[int]appliedMigration, error) {
+	timestamp := "TIMESTAMPTZ"
+	if db.Dialector.Name() == "sqlite" {
+		timestamp = "DATETIME"
+	}
+	err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at ` + timestamp + ` NOT NULL
+		)`).Error
+	if err != nil {
+		return nil, fmt.Errorf("could not create schema_migrations: %v", err)
+	}
+	var rows []appliedMigration
+	if err := db.Raw("SELECT version, name, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// checkKnown refuses to touch a database that has migrations this binary
+// does not know about, it was migrated by a newer version
+func checkKnown(migrations []migration, applied map[int]appliedMigration) error {
+	for version, row := range applied {
+		if version > len(migrations) {
+			return fmt.Errorf("database has migration %d_%s, which this build does not know; upgrade the backend", version, row.Name)
+		}
+	}
+	return nil
+}
+
+// migrateUp applies every pending migration up to and including target, or
+// all of them when target is 0. Each migration runs in its own transaction.
+func migrateUp(db *gorm.DB, migrations []migration, target int) ([]migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKnown(migrations, applied); err != nil {
+		return nil, err
+	}
+	var done []migration
+	for _, mig := range migrations {
+		if target > 0 && mig.Version > target {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+				mig.Version, mig.Name, time.Now()).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// migrateDown reverts the last steps applied migrations, newest first
+func migrateDown(db *gorm.DB, migrations []migration, steps int) ([]migration, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKnown(migrations, applied); err != nil {
+		return nil, err
+	}
+	var done []migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return err
+			}
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// migrateDatabase brings the schema up to date before the backend uses it
+func migrateDatabase(db *gorm.DB) {
+	migrations, err := embeddedMigrations(db.Dialector.Name())
+	if err != nil {
+		log.Fatalf("could not load migrations: %v", err)
+	}
+	done, err := migrateUp(db, migrations, 0)
+	for _, mig := range done {
+		log.Printf("migrate: applied %d_%s", mig.Version, mig.Name)
+	}
+	if err != nil {
+		log.Fatalf("could not migrate database: %v", err)
+	}
+}
+
+// migrateCommand implements `backend migrate up [-to N] | down [-steps N] | status`
+func migrateCommand(cfg Config, args []string) {
+	usage := errors.New("usage: migrate up [-to N] | down [-steps N] | status")
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+	switch args[0] {
+	case "up", "down", "status":
+	default:
+		log.Fatal(usage)
+	}
+	db := connectDB(cfg.Database)
+	migrations, err := embeddedMigrations(db.Dialector.Name())
+	if err != nil {
+		log.Fatalf("could not load migrations: %v", err)
+	}
+
+	var done []migration
+	switch args[0] {
+	case "up":
+		fs := flag.NewFlagSet("migrate up", flag.ExitOnError)
+		to := fs.Int("to", 0, "stop after this version (0 applies everything)")
+		fs.Parse(args[1:])
+		done, err = migrateUp(db, migrations, *to)
+		for _, mig := range done {
+			fmt.Printf("applied %d_%s\n", mig.Version, mig.Name)
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		fs.Parse(args[1:])
+		if *steps < 1 {
+			log.Fatal("migrate: -steps must be at least 1")
+		}
+		done, err = migrateDown(db, migrations, *steps)
+		for _, mig := range done {
+			fmt.Printf("reverted %d_%s\n", mig.Version, mig.Name)
+		}
+	case "status":
+		err = printMigrationStatus(db, migrations)
+	}
+	if err != nil {
+		log.Fatalf("migrate: %v", err)
+	}
+	if args[0] != "status" && len(done) == 0 {
+		fmt.Println("nothing to do")
+	}
+}
+
+func printMigrationStatus(db *gorm.DB, migrations []migration) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, mig := range migrations {
+		at := "pending"
+		if row, ok := applied[mig.Version]; ok {
+			at = row.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", mig.Version, mig.Name, at)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return checkKnown(migrations, applied)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	postgres, err := embeddedMigrations("postgres")
+	if err != nil {
+		t.Fatalf("embeddedMigrations(postgres): %v", err)
+	}
+	sqlite, err := embeddedMigrations("sqlite")
+	if err != nil {
+		t.Fatalf("embeddedMigrations(sqlite): %v", err)
+	}
+	if len(postgres) == 0 || postgres[0].Name != "base_schema" {
+		t.Fatalf("unexpected migrations: %+v", postgres)
+	}
+	// Both dialects have to be kept in step, a database is migrated by number
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("%d sqlite migrations, %d postgres migrations", len(sqlite), len(postgres))
+	}
+	for i := range postgres {
+		if sqlite[i].Name != postgres[i].Name {
+			t.Errorf("migration %d is %s for postgres but %s for sqlite", i+1, postgres[i].Name, sqlite[i].Name)
+		}
+	}
+
+	// Every table the backend writes is created by some migration
+	tables := []string{
+		"mentor", "student", "github", "repository", "leetcode", "problems", "mentor_sessions",
+		"mentor_credentials", "student_credentials", "leetcode_snapshots", "repo_language",
+		"refresh_status", "resume_text", "resume_skill", "resume_version", "deleted_students",
+		"student_audit", "company", "drive", "application", "application_event", "chat_message",
+	}
+	for dialect, migrations := range map[string][]migration{"postgres": postgres, "sqlite": sqlite} {
+		for _, table := range tables {
+			created := false
+			for _, mig := range migrations {
+				if strings.Contains(mig.Up, "CREATE TABLE IF NOT EXISTS "+table+" (") {
+					created = true
+				}
+			}
+			if !created {
+				t.Errorf("no %s migration creates %s", dialect, table)
+			}
+		}
+	}
+}
+
+func TestLoadMigrationsRejectsBadSets(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	tests := []struct {
+		fs   fstest.MapFS
+		want string
+	}{
+		{fstest.MapFS{"0001_a.up.sql": file("x")}, "needs both"},
+		{fstest.MapFS{"0001_a.up.sql": file("x"), "0001_b.down.sql": file("x")}, "named both"},
+		{fstest.MapFS{"0002_a.up.sql": file("x"), "0002_a.down.sql": file("x")}, "migration 1 is missing"},
+		{fstest.MapFS{"notes.txt": file("x")}, "unexpected migration file"},
+	}
+	for _, tt := range tests {
+		_, err := loadMigrations(tt.fs)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loadMigrations(%v) = %v, want an error about %q", tt.fs, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS mentor_sessions;
+DROP TABLE IF EXISTS problems;
+DROP TABLE IF EXISTS leetcode;
+DROP TABLE IF EXISTS repository;
+DROP TABLE IF EXISTS github;
+DROP TABLE IF EXISTS student;
+DROP TABLE IF EXISTS mentor;
@@ -0,0 +1,107 @@
+-- The tables the importer and onboarding write. Databases set up before
+-- migrations existed already have them, so every statement here leaves an
+-- existing table alone and only fills in what is missing.
+
+CREATE TABLE IF NOT EXISTS mentor (
+    mentor_id   SERIAL PRIMARY KEY,
+    mentor_name VARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS student (
+    student_id TEXT PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    phone_no   VARCHAR(15),
+    dob        DATE,
+    gender     VARCHAR(10),
+    resume     TEXT NOT NULL DEFAULT '',
+    sem        INTEGER,
+    mentor_id  INTEGER NOT NULL,
+    cgpa       NUMERIC(4,2),
+    email      VARCHAR(255),
+    age        INTEGER,
+    linkedin   TEXT NOT NULL DEFAULT '',
+    degree     TEXT NOT NULL DEFAULT '',
+    stream     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS github (
+    github_id  TEXT PRIMARY KEY,
+    student_id TEXT NOT NULL,
+    username   VARCHAR(50) NOT NULL,
+    bio        TEXT NOT NULL DEFAULT '',
+    repo_count TEXT NOT NULL DEFAULT '0'
+);
+
+CREATE TABLE IF NOT EXISTS repository (
+    repo_id     TEXT PRIMARY KEY,
+    github_id   TEXT NOT NULL,
+    repo_name   VARCHAR(100) NOT NULL,
+    language    TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS leetcode (
+    leetcode_id TEXT PRIMARY KEY,
+    student_id  TEXT NOT NULL,
+    username    VARCHAR(50) NOT NULL,
+    ranking     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS problems (
+    problem_id  INTEGER PRIMARY KEY,
+    leetcode_id TEXT NOT NULL,
+    no_easy     INTEGER NOT NULL DEFAULT 0,
+    no_medium   INTEGER NOT NULL DEFAULT 0,
+    no_hard     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS mentor_sessions (
+    session_id BIGSERIAL,
+    mentor_id  INTEGER NOT NULL,
+    student_id TEXT NOT NULL,
+    date       DATE NOT NULL,
+    advice     TEXT NOT NULL DEFAULT ''
+);
+
+-- Older mentor_sessions tables have no session_id and keep the date as text
+ALTER TABLE mentor_sessions ADD COLUMN IF NOT EXISTS session_id BIGSERIAL;
+CREATE UNIQUE INDEX IF NOT EXISTS mentor_sessions_session_id_idx ON mentor_sessions (session_id);
+DO $$
+BEGIN
+    IF (SELECT data_type FROM information_schema.columns
+        WHERE table_name = 'mentor_sessions' AND column_name = 'date') <> 'date' THEN
+        ALTER TABLE mentor_sessions ALTER COLUMN date TYPE DATE USING date::date;
+    END IF;
+END $$;
+
+-- Foreign keys use the names Postgres would generate, so keys that an older
+-- database already has are recognised and not added twice. Adding a key
+-- fails if existing rows point at missing parents; those rows have to be
+-- cleaned up by hand before migrating.
+DO $$
+DECLARE
+    fk RECORD;
+BEGIN
+    FOR fk IN SELECT * FROM (VALUES
+        ('student', 'student_mentor_id_fkey', 'mentor_id', 'mentor (mentor_id)', 'RESTRICT'),
+        ('github', 'github_student_id_fkey', 'student_id', 'student (student_id)', 'CASCADE'),
+        ('repository', 'repository_github_id_fkey', 'github_id', 'github (github_id)', 'CASCADE'),
+        ('leetcode', 'leetcode_student_id_fkey', 'student_id', 'student (student_id)', 'CASCADE'),
+        ('problems', 'problems_leetcode_id_fkey', 'leetcode_id', 'leetcode (leetcode_id)', 'CASCADE'),
+        ('mentor_sessions', 'mentor_sessions_mentor_id_fkey', 'mentor_id', 'mentor (mentor_id)', 'RESTRICT'),
+        ('mentor_sessions', 'mentor_sessions_student_id_fkey', 'student_id', 'student (student_id)', 'CASCADE')
+    ) AS t (tbl, name, col, parent, on_delete)
+    LOOP
+        IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = fk.name) THEN
+            EXECUTE format('ALTER TABLE %I ADD CONSTRAINT %I FOREIGN KEY (%I) REFERENCES %s ON DELETE %s',
+                fk.tbl, fk.name, fk.col, fk.parent, fk.on_delete);
+        END IF;
+    END LOOP;
+END $$;
+
+CREATE INDEX IF NOT EXISTS student_mentor_idx ON student (mentor_id);
+CREATE INDEX IF NOT EXISTS github_student_idx ON github (student_id);
+CREATE INDEX IF NOT EXISTS repository_github_idx ON repository (github_id);
+CREATE INDEX IF NOT EXISTS leetcode_student_idx ON leetcode (student_id);
+CREATE INDEX IF NOT EXISTS problems_leetcode_idx ON problems (leetcode_id);
+CREATE INDEX IF NOT EXISTS mentor_sessions_student_idx ON mentor_sessions (student_id, date);
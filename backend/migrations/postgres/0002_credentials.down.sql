@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS student_credentials;
+DROP TABLE IF EXISTS mentor_credentials;
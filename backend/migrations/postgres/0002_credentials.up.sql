@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS mentor_credentials (
+    mentor_id     INTEGER PRIMARY KEY REFERENCES mentor (mentor_id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS student_credentials (
+    student_id    TEXT PRIMARY KEY REFERENCES student (student_id) ON DELETE CASCADE,
+    password_hash TEXT NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS repo_language (
+    repo_id    TEXT NOT NULL REFERENCES repository (repo_id) ON DELETE CASCADE,
+    language   TEXT NOT NULL,
+    bytes      BIGINT NOT NULL,
+    percentage NUMERIC(5,2) NOT NULL,
+    PRIMARY KEY (repo_id, language)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS resume_skill;
+DROP TABLE IF EXISTS resume_text;
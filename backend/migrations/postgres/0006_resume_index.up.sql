@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS resume_text (
+    student_id    TEXT PRIMARY KEY REFERENCES student (student_id) ON DELETE CASCADE,
+    text          TEXT NOT NULL,
+    cgpa_mentions TEXT NOT NULL DEFAULT '[]',
+    internships   TEXT NOT NULL DEFAULT '[]',
+    indexed_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS resume_skill (
+    student_id TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    skill      TEXT NOT NULL,
+    category   TEXT NOT NULL,
+    mentions   INTEGER NOT NULL,
+    PRIMARY KEY (student_id, skill)
+);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS deleted_students (
+    student_id       TEXT PRIMARY KEY,
+    name             TEXT NOT NULL,
+    mentor_id        INTEGER NOT NULL,
+    deleted_at       TIMESTAMPTZ NOT NULL,
+    deleted_by       TEXT NOT NULL,
+    restorable_until TIMESTAMPTZ NOT NULL,
+    data             BYTEA NOT NULL
+);
+
+-- The audit trail has no foreign key, it has to outlive the student
+CREATE TABLE IF NOT EXISTS student_audit (
+    audit_id   BIGSERIAL PRIMARY KEY,
+    student_id TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    actor      TEXT NOT NULL,
+    at         TIMESTAMPTZ NOT NULL DEFAULT now(),
+    detail     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS student_audit_student_idx ON student_audit (student_id, at);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS company (
+    company_id  SERIAL PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    website     TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS drive (
+    drive_id            SERIAL PRIMARY KEY,
+    company_id          INTEGER NOT NULL REFERENCES company (company_id) ON DELETE CASCADE,
+    title               TEXT NOT NULL,
+    role                TEXT NOT NULL DEFAULT '',
+    package_lpa         NUMERIC(8,2) NOT NULL DEFAULT 0,
+    location            TEXT NOT NULL DEFAULT '',
+    drive_date          DATE,
+    deadline            DATE,
+    min_cgpa            NUMERIC(4,2),
+    degrees             TEXT NOT NULL DEFAULT '[]',
+    streams             TEXT NOT NULL DEFAULT '[]',
+    min_sem             INTEGER,
+    max_sem             INTEGER,
+    min_leetcode_solved INTEGER,
+    required_languages  TEXT NOT NULL DEFAULT '[]',
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS drive_company_idx ON drive (company_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS application_event;
+DROP TABLE IF EXISTS application;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS chat_message (
+    message_id BIGSERIAL PRIMARY KEY,
+    student_id TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    actor      TEXT NOT NULL,
+    role       TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    at         TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS chat_message_student_idx ON chat_message (student_id, actor, at);
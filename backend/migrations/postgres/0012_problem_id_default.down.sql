@@ -0,0 +1,2 @@
+ALTER TABLE problems ALTER COLUMN problem_id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS problems_problem_id_seq;
@@ -0,0 +1,6 @@
+-- problem_id used to be handed out as MAX(problem_id) + 1, which gives the
+-- same id to inserts that run at the same time. A sequence starting after
+-- the current maximum takes over.
+CREATE SEQUENCE IF NOT EXISTS problems_problem_id_seq OWNED BY problems.problem_id;
+SELECT setval('problems_problem_id_seq', COALESCE((SELECT MAX(problem_id) FROM problems), 0) + 1, false);
+ALTER TABLE problems ALTER COLUMN problem_id SET DEFAULT nextval('problems_problem_id_seq');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS application_one_accepted_idx;
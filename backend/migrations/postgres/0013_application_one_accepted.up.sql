@@ -0,0 +1,3 @@
+-- A student accepts at most one offer. moveApplication checks this first, the
+-- index keeps two concurrent accepts from both getting through.
+CREATE UNIQUE INDEX IF NOT EXISTS application_one_accepted_idx ON application (student_id) WHERE stage = 'accepted';
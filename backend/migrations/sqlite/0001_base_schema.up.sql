@@ -0,0 +1,70 @@
+-- SQLite version of the Postgres base schema, for local development and
+-- demos. Dates and timestamps are stored as text by the driver.
+
+CREATE TABLE IF NOT EXISTS mentor (
+    mentor_id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    mentor_name VARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS student (
+    student_id TEXT PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    phone_no   VARCHAR(15),
+    dob        DATE,
+    gender     VARCHAR(10),
+    resume     TEXT NOT NULL DEFAULT '',
+    sem        INTEGER,
+    mentor_id  INTEGER NOT NULL REFERENCES mentor (mentor_id) ON DELETE RESTRICT,
+    cgpa       NUMERIC(4,2),
+    email      VARCHAR(255),
+    age        INTEGER,
+    linkedin   TEXT NOT NULL DEFAULT '',
+    degree     TEXT NOT NULL DEFAULT '',
+    stream     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS github (
+    github_id  TEXT PRIMARY KEY,
+    student_id TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    username   VARCHAR(50) NOT NULL,
+    bio        TEXT NOT NULL DEFAULT '',
+    repo_count TEXT NOT NULL DEFAULT '0'
+);
+
+CREATE TABLE IF NOT EXISTS repository (
+    repo_id     TEXT PRIMARY KEY,
+    github_id   TEXT NOT NULL REFERENCES github (github_id) ON DELETE CASCADE,
+    repo_name   VARCHAR(100) NOT NULL,
+    language    TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS leetcode (
+    leetcode_id TEXT PRIMARY KEY,
+    student_id  TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    username    VARCHAR(50) NOT NULL,
+    ranking     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS problems (
+    problem_id  INTEGER PRIMARY KEY,
+    leetcode_id TEXT NOT NULL REFERENCES leetcode (leetcode_id) ON DELETE CASCADE,
+    no_easy     INTEGER NOT NULL DEFAULT 0,
+    no_medium   INTEGER NOT NULL DEFAULT 0,
+    no_hard     INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS mentor_sessions (
+    session_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    mentor_id  INTEGER NOT NULL REFERENCES mentor (mentor_id) ON DELETE RESTRICT,
+    student_id TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    date       DATE NOT NULL,
+    advice     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS student_mentor_idx ON student (mentor_id);
+CREATE INDEX IF NOT EXISTS github_student_idx ON github (student_id);
+CREATE INDEX IF NOT EXISTS repository_github_idx ON repository (github_id);
+CREATE INDEX IF NOT EXISTS leetcode_student_idx ON leetcode (student_id);
+CREATE INDEX IF NOT EXISTS problems_leetcode_idx ON problems (leetcode_id);
+CREATE INDEX IF NOT EXISTS mentor_sessions_student_idx ON mentor_sessions (student_id, date);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS leetcode_snapshots (
+    snapshot_id  INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id   TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    taken_at     DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    no_easy      INTEGER NOT NULL,
+    no_medium    INTEGER NOT NULL,
+    no_hard      INTEGER NOT NULL,
+    total_solved INTEGER NOT NULL,
+    ranking      INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS leetcode_snapshots_student_idx ON leetcode_snapshots (student_id, taken_at);
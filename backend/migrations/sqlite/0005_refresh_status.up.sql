@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS refresh_status (
+    student_id   TEXT PRIMARY KEY REFERENCES student (student_id) ON DELETE CASCADE,
+    last_attempt DATETIME NOT NULL,
+    last_success DATETIME,
+    status       TEXT NOT NULL,
+    error        TEXT NOT NULL DEFAULT ''
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS resume_version (
+    version_id  INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id  TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    storage_key TEXT NOT NULL,
+    sha256      TEXT NOT NULL,
+    size        BIGINT NOT NULL,
+    uploaded_at DATETIME NOT NULL,
+    uploaded_by TEXT NOT NULL,
+    UNIQUE (student_id, version)
+);
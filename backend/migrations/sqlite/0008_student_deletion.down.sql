@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS student_audit;
+DROP TABLE IF EXISTS deleted_students;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS deleted_students (
+    student_id       TEXT PRIMARY KEY,
+    name             TEXT NOT NULL,
+    mentor_id        INTEGER NOT NULL,
+    deleted_at       DATETIME NOT NULL,
+    deleted_by       TEXT NOT NULL,
+    restorable_until DATETIME NOT NULL,
+    data             BLOB NOT NULL
+);
+
+-- The audit trail has no foreign key, it has to outlive the student
+CREATE TABLE IF NOT EXISTS student_audit (
+    audit_id   INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_id TEXT NOT NULL,
+    action     TEXT NOT NULL,
+    actor      TEXT NOT NULL,
+    at         DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    detail     TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS student_audit_student_idx ON student_audit (student_id, at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS drive;
+DROP TABLE IF EXISTS company;
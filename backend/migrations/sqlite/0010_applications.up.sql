@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS application (
+    application_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    drive_id       INTEGER NOT NULL REFERENCES drive (drive_id) ON DELETE CASCADE,
+    student_id     TEXT NOT NULL REFERENCES student (student_id) ON DELETE CASCADE,
+    stage          TEXT NOT NULL,
+    package_lpa    NUMERIC(8,2),
+    applied_at     DATETIME NOT NULL,
+    updated_at     DATETIME NOT NULL,
+    UNIQUE (drive_id, student_id)
+);
+
+CREATE INDEX IF NOT EXISTS application_student_idx ON application (student_id);
+
+CREATE TABLE IF NOT EXISTS application_event (
+    event_id       INTEGER PRIMARY KEY AUTOINCREMENT,
+    application_id INTEGER NOT NULL REFERENCES application (application_id) ON DELETE CASCADE,
+    from_stage     TEXT NOT NULL DEFAULT '',
+    to_stage       TEXT NOT NULL,
+    at             DATETIME NOT NULL,
+    actor          TEXT NOT NULL,
+    note           TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS application_event_application_idx ON application_event (application_id, at);
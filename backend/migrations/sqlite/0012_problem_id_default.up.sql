@@ -0,0 +1,2 @@
+-- problem_id is an INTEGER PRIMARY KEY, so SQLite already assigns it when an
+-- insert leaves it out. Only the Postgres schema needed a sequence.
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// onboardingRequest is everything needed to add a student
+type onboardingRequest struct {
+	Name        string
+	SRN         string
+	Sem         int
+	CGPA        float64
+	Age         int
+	PhoneNo     string
+	Dob         time.Time // zero when not known, stored as NULL
+	Email       string
+	Degree      string
+	Stream      string
+	Gender      string
+	GithubURL   string
+	LeetcodeURL string
+	MentorName  string
+	MentorID    int // used instead of MentorName when set
+	ResumeURL   string
+	Linkedin    string
+	Password    string // the student logs in with it, see StudentLogin
+}
+
+// onboardingError names the onboarding step that failed
+type onboardingError struct {
+	Step   string
+	Status int
+	Err    error
+}
+
+func (e *onboardingError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *onboardingError) Unwrap() error {
+	return e.Err
+}
+
+func stepFailed(step string, status int, err error) error {
+	return &onboardingError{Step: step, Status: status, Err: err}
+}
+
+// writeOnboardingError responds with an apiError naming the failed step
+func writeOnboardingError(w http.ResponseWriter, err error) {
+	status, step := http.StatusInternalServerError, "unknown"
+	var oe *onboardingError
+	if errors.As(err, &oe) {
+		status, step = oe.Status, oe.Step
+		err = oe.Err
+	}
+	writeAPIError(w, status, apiError{Error: err.Error(), Step: step})
+}
+
+// onboardingResult summarises what was stored for a new student
+type onboardingResult struct {
+	SRN          string `json:"srn"`
+	Repositories int    `json:"repositories"`
+	Leetcode     bool   `json:"leetcode"`
+	Resume       string `json:"resume"`
+}
+
+// onboardStudent fetches the student's GitHub and LeetCode data and the resume,
+// then stores everything in one transaction. Nothing is left behind when a
+// step fails.
+func onboardStudent(ctx context.Context, db *gorm.DB, req onboardingRequest) (onboardingResult, error) {
+	result := onboardingResult{SRN: req.SRN}
+
+	mentorID := req.MentorID
+	if mentorID == 0 {
+		id, err := fetchMentorID(db, req.MentorName)
+		if err != nil || id == 0 {
+			return result, stepFailed("lookup_mentor", http.StatusBadRequest, fmt.Errorf("unknown mentor %q", req.MentorName))
+		}
+		mentorID = id
+	}
+	// Checked up front so a duplicate does not overwrite the existing resume
+	exists, err := rowExists(db, "SELECT 1 FROM student WHERE student_id = ?", req.SRN)
+	if err != nil {
+		return result, stepFailed("insert_student", http.StatusInternalServerError, err)
+	}
+	if exists {
+		return result, stepFailed("insert_student", http.StatusConflict, fmt.Errorf("student %s already exists", req.SRN))
+	}
+
+	// Everything that goes over the network happens before the transaction
+	// is opened, so it is not held open while waiting on GitHub or LeetCode.
+	var profile ProfileData
+	if req.GithubURL != "" {
+		username, err := getUsernameFromURL(req.GithubURL)
+		if err != nil {
+			return result, stepFailed("parse_github_url", http.StatusBadRequest, err)
+		}
+		if profile, err = githubClient.FetchProfile(ctx, username); err != nil {
+			return result, stepFailed("fetch_github", http.StatusBadGateway, err)
+		}
+	}
+	var leetProfile LeetCodeProfile
+	var leetUsername string
+	if req.LeetcodeURL != "" {
+		if leetUsername, err = getUsernameFromURL(req.LeetcodeURL); err != nil {
+			return result, stepFailed("parse_leetcode_url", http.StatusBadRequest, err)
+		}
+		if leetProfile, err = leetcodeProvider.FetchProfile(ctx, leetUsername); err != nil {
+			return result, stepFailed("fetch_leetcode", http.StatusBadGateway, err)
+		}
+	}
+	var passwordHash []byte
+	if req.Password != "" {
+		if passwordHash, err = bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost); err != nil {
+			return result, stepFailed("hash_password", http.StatusBadRequest, err)
+		}
+	}
+	resume, resumeData, err := importResumeFromDrive(ctx, req.SRN, req.ResumeURL)
+	if err != nil {
+		return result, stepFailed("import_resume", http.StatusBadGateway, err)
+	}
+	resumeKey := ""
+	if resume != nil {
+		resumeKey = resume.Key
+	}
+	result.Resume = resumeKey
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		student := Student{
+			StudentID: req.SRN,
+			Name:      req.Name,
+			PhoneNo:   req.PhoneNo,
+			Dob:       req.Dob,
+			Gender:    req.Gender,
+			Resume:    resumeKey,
+			Sem:       req.Sem,
+			MentorID:  mentorID,
+			CGPA:      req.CGPA,
+			Email:     req.Email,
+			Age:       req.Age,
+			Linkedin:  req.Linkedin,
+			Degree:    req.Degree,
+			Stream:    req.Stream,
+		}
+		if err := insertStudent(tx, student); err != nil {
+			return stepFailed("insert_student", http.StatusConflict, err)
+		}
+		if passwordHash != nil {
+			err := tx.Exec(`INSERT INTO student_credentials (student_id, password_hash, updated_at)
+				VALUES ($1, $2, $3)`, req.SRN, string(passwordHash), time.Now()).Error
+			if err != nil {
+				return stepFailed("insert_credentials", http.StatusInternalServerError, err)
+			}
+		}
+		if resume != nil {
+			if err := insertResumeVersion(tx, *resume); err != nil {
+				return stepFailed("insert_resume_version", http.StatusInternalServerError, err)
+			}
+		}
+
+		if req.GithubURL != "" {
+			git := Github{
+				GithubID:  req.GithubURL,
+				StudentID: req.SRN,
+				Username:  profile.Username,
+				Bio:       profile.Bio,
+				RepoCount: profile.RepoCount,
+			}
+			if err := insertGithub(tx, git); err != nil {
+				return stepFailed("insert_github", http.StatusConflict, err)
+			}
+			for _, repo := range profile.PinnedRepos {
+				repository := Repository{
+					RepoID:   req.GithubURL + "/" + repo.Name,
+					GithubID: req.GithubURL,
+					RepoName: repo.Name,
+					Language: strings.Join(repo.Languages, ", "),
+					Desc:     repo.About,
+				}
+				if err := insertRepository(tx, repository); err != nil {
+					return stepFailed("insert_repository", http.StatusConflict, err)
+				}
+				if err := replaceRepoLanguages(tx, repository.RepoID, repo.LanguageBytes); err != nil {
+					return stepFailed("insert_repo_languages", http.StatusInternalServerError, err)
+				}
+				result.Repositories++
+			}
+		}
+
+		if req.LeetcodeURL != "" {
+			leetcodeData := LeetCode{
+				LeetCodeID: req.LeetcodeURL,
+				StudentID:  req.SRN,
+				Username:   leetProfile.Username,
+				Rank:       leetProfile.Ranking,
+			}
+			if err := insertLeetCode(tx, leetcodeData); err != nil {
+				return stepFailed("insert_leetcode", http.StatusConflict, err)
+			}
+			problems := Problems{
+				LeetcodeID: fmt.Sprintf("https://leetcode.com/%s", leetUsername),
+				NoEasy:     leetProfile.EasySolved,
+				NoMedium:   leetProfile.MediumSolved,
+				NoHard:     leetProfile.HardSolved,
+			}
+			if err := insertProblems(tx, problems); err != nil {
+				return stepFailed("insert_problems", http.StatusConflict, err)
+			}
+			if err := recordLeetCodeSnapshot(tx, req.SRN, leetProfile); err != nil {
+				return stepFailed("record_leetcode_snapshot", http.StatusInternalServerError, err)
+			}
+			result.Leetcode = true
+		}
+		return nil
+	})
+	if err != nil {
+		if resume != nil {
+			resumeStore.Delete(context.Background(), resume.Key)
+		}
+		var oe *onboardingError
+		if !errors.As(err, &oe) {
+			err = stepFailed("commit", http.StatusInternalServerError, err)
+		}
+		return result, err
+	}
+	if resume != nil {
+		if _, err := indexResume(db, req.SRN, resumeData); err != nil {
+			log.Printf("could not index resume of %s: %v", req.SRN, err)
+		}
+	}
+	return result, nil
+}
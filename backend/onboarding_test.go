@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnboardStudentLeavesUnknownDobNull(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+
+	_, err := onboardStudent(context.Background(), db, onboardingRequest{
+		Name: "Deepa", SRN: "PES2UG22CS104", Sem: 3, CGPA: 8.4, MentorName: "Asha Rao",
+	})
+	if err != nil {
+		t.Fatalf("onboardStudent: %v", err)
+	}
+	var nullDob bool
+	if err := db.Raw("SELECT dob IS NULL FROM student WHERE student_id = ?", "PES2UG22CS104").Scan(&nullDob).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !nullDob {
+		t.Error("dob was stored for a student who did not give one")
+	}
+	if _, found, err := newSQLStore(db).Student("PES2UG22CS104"); err != nil || !found {
+		t.Errorf("Student = %v, %v", found, err)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshConfig controls the background refresh of GitHub and LeetCode data
+type RefreshConfig struct {
+	Interval    time.Duration // zero disables the scheduler
+	Concurrency int
+}
+
+// RefreshStatus is the outcome of the last refresh of one student
+type RefreshStatus struct {
+	StudentID   string     `gorm:"primaryKey;column:student_id" json:"srn"`
+	LastAttempt time.Time  `gorm:"column:last_attempt" json:"last_attempt"`
+	LastSuccess *time.Time `gorm:"column:last_success" json:"last_success"`
+	Status      string     `gorm:"column:status" json:"status"`
+	Error       string     `gorm:"column:error" json:"error,omitempty"`
+}
+
+func (RefreshStatus) TableName() string {
+	return "refresh_status"
+}
+
+// refreshTarget is a student together with their linked profiles
+type refreshTarget struct {
+	StudentID  string
+	GithubID   string
+	LeetcodeID string
+}
+
+// refreshStudent re-fetches both profiles of a student and updates the rows
+// in place. It keeps going after a failure so one bad profile does not stop
+// the other from being refreshed.
+func refreshStudent(db *gorm.DB, t refreshTarget) error {
+	report := newImportReport()
+	var errs []error
+	if t.GithubID != "" {
+		if err := syncGithub(db, t.StudentID, t.GithubID, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if t.LeetcodeID != "" {
+		if err := syncLeetCode(db, t.StudentID, t.LeetcodeID, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func recordRefreshStatus(db *gorm.DB, srn string, at time.Time, refreshErr error) error {
+	if refreshErr == nil {
+		return db.Exec(`
+			INSERT INTO refresh_status (student_id, last_attempt, last_success, status, error)
+			VALUES ($1, $2, $2, 'ok', '')
+			ON CONFLICT (student_id) DO UPDATE
+			SET last_attempt = EXCLUDED.last_attempt, last_success = EXCLUDED.last_success, status = 'ok', error = ''`,
+			srn, at).Error
+	}
+	return db.Exec(`
+		INSERT INTO refresh_status (student_id, last_attempt, status, error)
+		VALUES ($1, $2, 'failed', $3)
+		ON CONFLICT (student_id) DO UPDATE
+		SET last_attempt = EXCLUDED.last_attempt, status = 'failed', error = EXCLUDED.error`,
+		srn, at, refreshErr.Error()).Error
+}
+
+// refreshAll refreshes every student, running at most concurrency
+// refreshes at the same time. Profiles are refreshed from the github and
+// leetcode rows, so a student whose profile could not be fetched during the
+// import has nothing to refresh; they are logged so the import can be re-run.
+func refreshAll(ctx context.Context, db *gorm.DB, concurrency int) {
+	var targets []refreshTarget
+	err := db.Raw(`
+		SELECT s.student_id, COALESCE(g.github_id, '') AS github_id, COALESCE(l.leetcode_id, '') AS leetcode_id
+		FROM student s
+		LEFT JOIN github g ON g.student_id = s.student_id
+		LEFT JOIN leetcode l ON l.student_id = s.student_id`).Scan(&targets).Error
+	if err != nil {
+		log.Printf("refresh: could not list students: %v", err)
+		return
+	}
+
+	queued := targets[:0]
+	for _, t := range targets {
+		var missing []string
+		if t.GithubID == "" {
+			missing = append(missing, "GitHub")
+		}
+		if t.LeetcodeID == "" {
+			missing = append(missing, "LeetCode")
+		}
+		if len(missing) > 0 {
+			log.Printf("refresh: %s has no %s profile stored, re-run the import to add it", t.StudentID, strings.Join(missing, " or "))
+		}
+		if len(missing) < 2 {
+			queued = append(queued, t)
+		}
+	}
+	skipped := len(targets) - len(queued)
+	targets = queued
+
+	jobs := make(chan refreshTarget)
+	var wg sync.WaitGroup
+	var failed int
+	var mu sync.Mutex
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				started := time.Now()
+				refreshErr := refreshStudent(db, t)
+				if refreshErr != nil {
+					log.Printf("refresh: %s: %v", t.StudentID, refreshErr)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+				if err := recordRefreshStatus(db, t.StudentID, started, refreshErr); err != nil {
+					log.Printf("refresh: could not record status for %s: %v", t.StudentID, err)
+				}
+			}
+		}()
+	}
+
+	start := time.Now()
+feed:
+	for _, t := range targets {
+		select {
+		case jobs <- t:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	log.Printf("refresh: %d students refreshed in %s, %d failed, %d skipped without profiles",
+		len(targets), time.Since(start).Round(time.Second), failed, skipped)
+}
+
+// startRefreshScheduler refreshes all students once at startup and then every
+// cfg.Interval until ctx is cancelled. A run that takes longer than the
+// interval delays the next one rather than overlapping with it.
+func startRefreshScheduler(ctx context.Context, db *gorm.DB, cfg RefreshConfig) {
+	if cfg.Interval == 0 {
+		log.Println("refresh: scheduler disabled")
+		return
+	}
+	log.Printf("refresh: every %s with %d workers", cfg.Interval, cfg.Concurrency)
+	go func() {
+		refreshAll(ctx, db, cfg.Concurrency)
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refreshAll(ctx, db, cfg.Concurrency)
+			}
+		}
+	}()
+}
+
+// GetRefreshStatus lists the last refresh of the mentor's students, or of a
+// single student with ?srn=
+func GetRefreshStatus(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "not allowed for this account", http.StatusForbidden)
+		return
+	}
+	query := `
+		SELECT rs.student_id, rs.last_attempt, rs.last_success, rs.status, rs.error
+		FROM refresh_status rs
+		JOIN student s ON s.student_id = rs.student_id
+		WHERE s.mentor_id = ?`
+	args := []interface{}{mentorID}
+	if srn := r.URL.Query().Get("srn"); srn != "" {
+		query += " AND rs.student_id = ?"
+		args = append(args, srn)
+	}
+	query += " ORDER BY rs.student_id"
+
+	statuses := []RefreshStatus{}
+	if err := db.Raw(query, args...).Scan(&statuses).Error; err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"students": statuses})
+}
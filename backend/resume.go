@@ -0,0 +1,529 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const maxResumeSize = 5 << 20
+
+var pdfMagic = []byte("%PDF-")
+
+var (
+	errResumeTooLarge = fmt.Errorf("resume must be at most %d MB", maxResumeSize>>20)
+	errResumeNotPDF   = errors.New("resume must be a PDF file")
+)
+
+// resumeKey is a new name to store an upload of a student's resume under. It
+// starts with the content hash and ends in a random part, so no two uploads
+// share a file, and removing the file of a failed upload cannot touch another
+// one.
+func resumeKey(srn, sha string) (string, error) {
+	nonce := make([]byte, 4)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s_Resume_%s_%s.pdf", srn, sha[:16], hex.EncodeToString(nonce)), nil
+}
+
+// ResumeVersion is one uploaded resume. The student's resume column points at
+// the key of the current version.
+type ResumeVersion struct {
+	VersionID  int64     `gorm:"primaryKey;column:version_id" json:"-"`
+	StudentID  string    `gorm:"column:student_id" json:"-"`
+	Version    int       `gorm:"column:version" json:"version"`
+	Key        string    `gorm:"column:storage_key" json:"-"`
+	SHA256     string    `gorm:"column:sha256" json:"sha256"`
+	Size       int64     `gorm:"column:size" json:"size"`
+	UploadedAt time.Time `gorm:"column:uploaded_at" json:"uploaded_at"`
+	UploadedBy string    `gorm:"column:uploaded_by" json:"uploaded_by"`
+	Current    bool      `gorm:"column:current;->" json:"current"`
+}
+
+func (ResumeVersion) TableName() string {
+	return "resume_version"
+}
+
+func insertResumeVersion(db *gorm.DB, v ResumeVersion) error {
+	err := db.Exec(`
+		INSERT INTO resume_version (student_id, version, storage_key, sha256, size, uploaded_at, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		v.StudentID, v.Version, v.Key, v.SHA256, v.Size, v.UploadedAt, v.UploadedBy).Error
+	if err != nil {
+		return fmt.Errorf("could not insert resume version: %v", err)
+	}
+	return nil
+}
+
+// newResumeVersion describes data as version n of the student's resume, under
+// a new storage key
+func newResumeVersion(srn string, n int, data []byte, actor string) (ResumeVersion, error) {
+	sum := sha256.Sum256(data)
+	v := ResumeVersion{
+		StudentID:  srn,
+		Version:    n,
+		SHA256:     hex.EncodeToString(sum[:]),
+		Size:       int64(len(data)),
+		UploadedAt: time.Now(),
+		UploadedBy: actor,
+	}
+	key, err := resumeKey(srn, v.SHA256)
+	if err != nil {
+		return v, fmt.Errorf("could not name resume file: %v", err)
+	}
+	v.Key = key
+	return v, nil
+}
+
+// fetchResumeVersions lists the versions of a student, newest first
+func fetchResumeVersions(db *gorm.DB, srn string) ([]ResumeVersion, error) {
+	versions := []ResumeVersion{}
+	err := db.Raw(`
+		SELECT rv.*, (rv.storage_key = s.resume) AS current
+		FROM resume_version rv
+		JOIN student s ON s.student_id = rv.student_id
+		WHERE rv.student_id = ?
+		ORDER BY rv.version DESC`, srn).Scan(&versions).Error
+	return versions, err
+}
+
+// adoptLegacyResume records a resume stored before versioning existed as
+// version 1, so it shows up in the history
+func adoptLegacyResume(ctx context.Context, db *gorm.DB, srn string) error {
+	var key string
+	if err := db.Raw("SELECT resume FROM student WHERE student_id = ?", srn).Scan(&key).Error; err != nil {
+		return err
+	}
+	if key == "" {
+		return nil
+	}
+	known, err := rowExists(db, "SELECT 1 FROM resume_version WHERE student_id = ?", srn)
+	if err != nil || known {
+		return err
+	}
+	f, err := resumeStore.Open(ctx, key)
+	if errors.Is(err, errFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	v, err := newResumeVersion(srn, 1, data, "legacy")
+	if err != nil {
+		return err
+	}
+	v.Key = key
+	return insertResumeVersion(db, v)
+}
+
+// storeResumeVersion keeps data as a new version and makes it current. When
+// data is the same as the current version nothing is stored and created is
+// false. The file is written under its own key first; the version number is
+// taken inside the transaction, with the student row locked, so concurrent
+// uploads get consecutive versions.
+func storeResumeVersion(ctx context.Context, db *gorm.DB, srn string, data []byte, actor string) (v ResumeVersion, created bool, err error) {
+	if err := adoptLegacyResume(ctx, db, srn); err != nil {
+		return v, false, err
+	}
+	v, err = newResumeVersion(srn, 0, data, actor)
+	if err != nil {
+		return v, false, err
+	}
+	if err := resumeStore.Put(ctx, v.Key, bytes.NewReader(data)); err != nil {
+		return v, false, err
+	}
+	var current ResumeVersion
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var currentKey string
+		res := tx.Raw("SELECT resume FROM student WHERE student_id = ?"+forUpdate(tx), srn).Scan(&currentKey)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errStudentNotFound
+		}
+		res = tx.Raw(`
+			SELECT * FROM resume_version
+			WHERE student_id = ? AND storage_key = ?`, srn, currentKey).Scan(&current)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected > 0 && current.SHA256 == v.SHA256 {
+			return nil
+		}
+		current = ResumeVersion{}
+		err := tx.Raw("SELECT COALESCE(MAX(version), 0) + 1 FROM resume_version WHERE student_id = ?", srn).Scan(&v.Version).Error
+		if err != nil {
+			return err
+		}
+		if err := insertResumeVersion(tx, v); err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE student SET resume = ? WHERE student_id = ?", v.Key, srn).Error
+	})
+	// Nothing refers to the new file unless the transaction stored it
+	if err != nil || current.Key != "" {
+		resumeStore.Delete(context.Background(), v.Key)
+	}
+	if err != nil {
+		return v, false, err
+	}
+	if current.Key != "" {
+		current.Current = true
+		return current, false, nil
+	}
+	v.Current = true
+	return v, true, nil
+}
+
+// readResume reads a whole PDF of at most maxResumeSize bytes
+func readResume(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxResumeSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResumeSize {
+		return nil, errResumeTooLarge
+	}
+	if !bytes.HasPrefix(data, pdfMagic) {
+		return nil, errResumeNotPDF
+	}
+	return data, nil
+}
+
+// UploadResume implements POST /students/{srn}/resume. The PDF is sent as the
+// "resume" field of a multipart form.
+func UploadResume(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, maxResumeSize+64<<10)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: "expected a multipart/form-data body"})
+		return
+	}
+	var data []byte
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeAPIError(w, http.StatusRequestEntityTooLarge, apiError{Error: errResumeTooLarge.Error()})
+			return
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid multipart body: " + err.Error()})
+			return
+		}
+		if part.FormName() != "resume" {
+			part.Close()
+			continue
+		}
+		data, err = readResume(part)
+		part.Close()
+		switch {
+		case errors.Is(err, errResumeTooLarge), errors.As(err, &maxErr):
+			writeAPIError(w, http.StatusRequestEntityTooLarge, apiError{Error: errResumeTooLarge.Error()})
+			return
+		case errors.Is(err, errResumeNotPDF):
+			writeAPIError(w, http.StatusUnsupportedMediaType, apiError{Error: err.Error()})
+			return
+		case err != nil:
+			writeAPIError(w, http.StatusBadRequest, apiError{Error: "could not read resume: " + err.Error()})
+			return
+		}
+		break
+	}
+	if data == nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{
+			Error:  "validation failed",
+			Fields: []fieldError{{Field: "resume", Message: "is required"}},
+		})
+		return
+	}
+
+	v, created, err := storeResumeVersion(r.Context(), db, srn, data, auditActor(r))
+	if err != nil {
+		log.Printf("failed to store resume of %s: %v", srn, err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to store resume"})
+		return
+	}
+	status := http.StatusOK
+	indexed := true
+	if created {
+		status = http.StatusCreated
+		// The upload is kept even if the PDF has no extractable text
+		if _, err := indexResume(db, srn, data); err != nil {
+			log.Printf("could not index resume of %s: %v", srn, err)
+			indexed = false
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"srn":     srn,
+		"version": v,
+		"created": created,
+		"indexed": indexed,
+	})
+}
+
+// GetResume serves the student's resume from resumeStore
+func GetResume(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn := r.URL.Query().Get("srn")
+	var key string
+	result := db.Raw("SELECT resume FROM student WHERE student_id = ?", srn).Scan(&key)
+	if result.Error != nil {
+		log.Printf("Couldn't retrieve record: %v", result.Error)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if result.RowsAffected == 0 {
+		http.Error(w, "Student not found", http.StatusNotFound)
+		return
+	}
+	if key == "" {
+		http.Error(w, "No resume uploaded", http.StatusNotFound)
+		return
+	}
+	file, err := resumeStore.Open(r.Context(), key)
+	if errors.Is(err, errFileNotFound) {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Could not open resume %s: %v", key, err)
+		http.Error(w, "Error serving file", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", "inline; filename=resume.pdf")
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Error while sending file: %v", err)
+	}
+}
+
+// importResumeFromDrive fetches a Google Drive resume link with gdown and puts
+// it into resumeStore as version 1 of a new student's resume. The caller
+// records the version once the student exists. It is only used when
+// onboarding is given a resume_url, so gdown does not have to be installed
+// otherwise. An empty link means the student has no resume yet.
+func importResumeFromDrive(ctx context.Context, srn, resumeURL string) (*ResumeVersion, []byte, error) {
+	if resumeURL == "" {
+		return nil, nil, nil
+	}
+	if _, err := exec.LookPath("gdown"); err != nil {
+		return nil, nil, errors.New("importing a resume link needs gdown, upload the PDF instead")
+	}
+	dir, err := os.MkdirTemp("", "placify-resume-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+	cmd := exec.CommandContext(ctx, "gdown", "--fuzzy", resumeURL, "-O", "resume.pdf")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.Printf("gdown output: %s", output)
+		return nil, nil, fmt.Errorf("could not download resume: %v", err)
+	}
+	f, err := os.Open(filepath.Join(dir, "resume.pdf"))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	data, err := readResume(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, err := newResumeVersion(srn, 1, data, "onboarding")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := resumeStore.Put(ctx, v.Key, bytes.NewReader(data)); err != nil {
+		return nil, nil, err
+	}
+	return &v, data, nil
+}
+
+// ListResumeVersions implements GET /students/{srn}/resume/versions. With
+// ?session_id= it also picks the versions current just before and after that
+// mentor session, for comparing them.
+func ListResumeVersions(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	if err := adoptLegacyResume(r.Context(), db, srn); err != nil {
+		log.Printf("could not adopt resume of %s: %v", srn, err)
+	}
+	versions, err := fetchResumeVersions(db, srn)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	response := map[string]interface{}{
+		"srn":      srn,
+		"versions": versions,
+	}
+
+	if v := r.URL.Query().Get("session_id"); v != "" {
+		sessionID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid session_id"})
+			return
+		}
+		session, found, err := newSQLStore(db).MentorSession(sessionID)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+			return
+		}
+		if !found || session.SRN != srn {
+			writeAPIError(w, http.StatusNotFound, apiError{Error: "session not found"})
+			return
+		}
+		date, _ := time.Parse(sessionDateLayout, session.Date)
+		before, after := versionsAround(versions, date)
+		response["session"] = session
+		response["before"] = before
+		response["after"] = after
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// versionsAround returns the version that was current at the start of day and
+// the newest version uploaded on or after it. versions is newest first.
+func versionsAround(versions []ResumeVersion, day time.Time) (before, after *ResumeVersion) {
+	for i := range versions {
+		v := &versions[i]
+		if v.UploadedAt.Before(day) {
+			if before == nil {
+				before = v
+			}
+		} else if after == nil {
+			after = v
+		}
+	}
+	return before, after
+}
+
+// fetchResumeVersion loads one version of a student's resume. It writes the
+// error response itself.
+func fetchResumeVersion(db *gorm.DB, w http.ResponseWriter, srn string, version int) (ResumeVersion, bool) {
+	var v ResumeVersion
+	res := db.Raw(`
+		SELECT rv.*, (rv.storage_key = s.resume) AS current
+		FROM resume_version rv
+		JOIN student s ON s.student_id = rv.student_id
+		WHERE rv.student_id = ? AND rv.version = ?`, srn, version).Scan(&v)
+	if res.Error != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return v, false
+	}
+	if res.RowsAffected == 0 {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "resume version not found"})
+		return v, false
+	}
+	return v, true
+}
+
+// GetResumeVersion implements GET /students/{srn}/resume/versions/{version}
+func GetResumeVersion(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid version"})
+		return
+	}
+	v, ok := fetchResumeVersion(db, w, srn, version)
+	if !ok {
+		return
+	}
+	file, err := resumeStore.Open(r.Context(), v.Key)
+	if errors.Is(err, errFileNotFound) {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "file not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Could not open resume %s: %v", v.Key, err)
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "error serving file"})
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=resume_v%d.pdf", v.Version))
+	w.Header().Set("ETag", `"`+v.SHA256+`"`)
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Error while sending file: %v", err)
+	}
+}
+
+// SetCurrentResume implements PUT /students/{srn}/resume/current with a
+// {"version": n} body, to go back to an earlier upload
+func SetCurrentResume(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Version *int `json:"version"`
+	}
+	if !decodeJSONBody(w, r, &body) {
+		return
+	}
+	if body.Version == nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{
+			Error:  "validation failed",
+			Fields: []fieldError{{Field: "version", Message: "is required"}},
+		})
+		return
+	}
+	v, ok := fetchResumeVersion(db, w, srn, *body.Version)
+	if !ok {
+		return
+	}
+	if err := db.Exec("UPDATE student SET resume = ? WHERE student_id = ?", v.Key, srn).Error; err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to update student"})
+		return
+	}
+	v.Current = true
+	if err := indexStoredResume(r.Context(), db, srn, v.Key); err != nil {
+		log.Printf("could not index resume of %s: %v", srn, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"srn":     srn,
+		"version": v,
+	})
+}
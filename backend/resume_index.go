@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+	"gorm.io/gorm"
+)
+
+// SkillEntry is one skill of the dictionary. Aliases always match regardless
+// of case, the name only does unless CaseSensitive is set (for names such as
+// "Go" that are also common words).
+type SkillEntry struct {
+	Name          string   `json:"name"`
+	Category      string   `json:"category"`
+	Aliases       []string `json:"aliases"`
+	CaseSensitive bool     `json:"case_sensitive"`
+}
+
+// SkillDictionary is the list of skills looked for in resumes
+type SkillDictionary struct {
+	Skills []SkillEntry `json:"skills"`
+
+	patterns []*regexp.Regexp
+}
+
+// skillDictionary is loaded from skills.json at startup
+var skillDictionary = &SkillDictionary{}
+
+// loadSkillDictionary reads the dictionary. A missing file gives an empty
+// dictionary, so only CGPA and internships are extracted.
+func loadSkillDictionary(path string) (*SkillDictionary, error) {
+	dict := &SkillDictionary{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return dict, nil
+	}
+	if err != nil {
+		return dict, err
+	}
+	if err := json.Unmarshal(data, dict); err != nil {
+		return dict, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	for _, entry := range dict.Skills {
+		if entry.Name == "" {
+			return dict, fmt.Errorf("%s: skill without a name", path)
+		}
+		dict.patterns = append(dict.patterns, skillPattern(entry))
+	}
+	return dict, nil
+}
+
+// skillPattern matches the name or any alias as a whole word. Characters
+// such as + and # count as part of a word, so "C++" does not match "C".
+func skillPattern(entry SkillEntry) *regexp.Regexp {
+	word := func(s string) string {
+		return `(?:^|[^\pL\pN+#])` + regexp.QuoteMeta(s) + `(?:$|[^\pL\pN+#])`
+	}
+	var alts []string
+	if entry.CaseSensitive {
+		alts = append(alts, word(entry.Name))
+	} else {
+		alts = append(alts, "(?i:"+word(entry.Name)+")")
+	}
+	for _, alias := range entry.Aliases {
+		alts = append(alts, "(?i:"+word(alias)+")")
+	}
+	return regexp.MustCompile(strings.Join(alts, "|"))
+}
+
+// ResumeSkill is a dictionary skill found in a resume
+type ResumeSkill struct {
+	Skill    string `json:"skill"`
+	Category string `json:"category"`
+	Mentions int    `json:"mentions"`
+}
+
+// ResumeAnalysis is what is extracted from the text of a resume
+type ResumeAnalysis struct {
+	Skills       []ResumeSkill `json:"skills"`
+	CGPAMentions []float64     `json:"cgpa_mentions"`
+	Internships  []string      `json:"internships"`
+}
+
+var (
+	cgpaMention       = regexp.MustCompile(`(?i)\b(?:c\.?g\.?p\.?a|gpa)\b[^0-9\n]{0,15}([0-9]{1,2}(?:\.[0-9]{1,2})?)`)
+	internshipMention = regexp.MustCompile(`(?i)\bintern(?:ship)?s?\b`)
+)
+
+const maxInternshipLine = 200 // bytes
+
+// analyzeResume finds the dictionary skills, CGPA figures and internship
+// lines in the plain text of a resume
+func analyzeResume(text string, dict *SkillDictionary) ResumeAnalysis {
+	analysis := ResumeAnalysis{Skills: []ResumeSkill{}, CGPAMentions: []float64{}, Internships: []string{}}
+	for i, entry := range dict.Skills {
+		if n := len(dict.patterns[i].FindAllStringIndex(text, -1)); n > 0 {
+			analysis.Skills = append(analysis.Skills, ResumeSkill{Skill: entry.Name, Category: entry.Category, Mentions: n})
+		}
+	}
+	sort.SliceStable(analysis.Skills, func(i, j int) bool {
+		return analysis.Skills[i].Mentions > analysis.Skills[j].Mentions
+	})
+
+	seenCGPA := map[float64]bool{}
+	for _, m := range cgpaMention.FindAllStringSubmatch(text, -1) {
+		v, err := strconv.ParseFloat(m[1], 64)
+		if err != nil || v <= 0 || v > 10 || seenCGPA[v] {
+			continue
+		}
+		seenCGPA[v] = true
+		analysis.CGPAMentions = append(analysis.CGPAMentions, v)
+	}
+
+	seenLine := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.Join(strings.Fields(scanner.Text()), " ")
+		if !internshipMention.MatchString(line) || seenLine[line] {
+			continue
+		}
+		seenLine[line] = true
+		if len(line) > maxInternshipLine {
+			// Cut before a whole character, bullets such as • take several bytes
+			cut := maxInternshipLine
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			line = line[:cut]
+		}
+		analysis.Internships = append(analysis.Internships, line)
+	}
+	return analysis
+}
+
+// extractResumeText returns the plain text of a PDF, one line per row of
+// text on the page
+func extractResumeText(data []byte) (text string, err error) {
+	// The PDF reader panics on some malformed files
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("could not parse PDF: %v", p)
+		}
+	}()
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("could not parse PDF: %v", err)
+	}
+	var buf strings.Builder
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, line := range textLines(page.Content().Text) {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String(), nil
+}
+
+// textLines groups the glyphs of a page into lines, top to bottom. A space
+// is added where there is a visible gap between two glyphs of a line.
+func textLines(glyphs []pdf.Text) []string {
+	rows := map[int][]pdf.Text{}
+	for _, g := range glyphs {
+		y := int(math.Round(g.Y))
+		rows[y] = append(rows[y], g)
+	}
+	ys := make([]int, 0, len(rows))
+	for y := range rows {
+		ys = append(ys, y)
+	}
+	// PDF coordinates grow upwards, so the top line has the largest y
+	sort.Sort(sort.Reverse(sort.IntSlice(ys)))
+
+	lines := make([]string, 0, len(ys))
+	for _, y := range ys {
+		row := rows[y]
+		sort.SliceStable(row, func(i, j int) bool { return row[i].X < row[j].X })
+		var b strings.Builder
+		for i, g := range row {
+			if i > 0 {
+				prev := row[i-1]
+				gap := g.X - (prev.X + prev.W)
+				if gap > prev.FontSize*0.15 && !strings.HasSuffix(prev.S, " ") && g.S != " " {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(g.S)
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// storeResumeIndex replaces the stored text and skills of a student
+func storeResumeIndex(db *gorm.DB, srn, text string, analysis ResumeAnalysis) error {
+	cgpa, _ := json.Marshal(analysis.CGPAMentions)
+	internships, _ := json.Marshal(analysis.Internships)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM resume_skill WHERE student_id = ?", srn).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM resume_text WHERE student_id = ?", srn).Error; err != nil {
+			return err
+		}
+		err := tx.Exec(`INSERT INTO resume_text (student_id, text, cgpa_mentions, internships, indexed_at)
+			VALUES ($1, $2, $3, $4, $5)`, srn, text, string(cgpa), string(internships), time.Now()).Error
+		if err != nil {
+			return fmt.Errorf("could not store resume text: %v", err)
+		}
+		for _, s := range analysis.Skills {
+			err := tx.Exec(`INSERT INTO resume_skill (student_id, skill, category, mentions)
+				VALUES ($1, $2, $3, $4)`, srn, s.Skill, s.Category, s.Mentions).Error
+			if err != nil {
+				return fmt.Errorf("could not store resume skill: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// indexResume extracts and stores the text and skills of a resume PDF
+func indexResume(db *gorm.DB, srn string, data []byte) (ResumeAnalysis, error) {
+	text, err := extractResumeText(data)
+	if err != nil {
+		return ResumeAnalysis{}, err
+	}
+	analysis := analyzeResume(text, skillDictionary)
+	return analysis, storeResumeIndex(db, srn, text, analysis)
+}
+
+// indexStoredResume indexes the resume already in resumeStore under key
+func indexStoredResume(ctx context.Context, db *gorm.DB, srn, key string) error {
+	f, err := resumeStore.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := readResume(f)
+	if err != nil {
+		return err
+	}
+	_, err = indexResume(db, srn, data)
+	return err
+}
+
+// GetResumeProfile returns the skills, CGPA figures and internships found in
+// the student's resume
+func GetResumeProfile(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn := r.URL.Query().Get("srn")
+	var row struct {
+		CGPAMentions string
+		Internships  string
+		IndexedAt    time.Time
+	}
+	res := db.Raw("SELECT cgpa_mentions, internships, indexed_at FROM resume_text WHERE student_id = ?", srn).Scan(&row)
+	if res.Error != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if res.RowsAffected == 0 {
+		http.Error(w, "Resume has not been indexed", http.StatusNotFound)
+		return
+	}
+	analysis := ResumeAnalysis{Skills: []ResumeSkill{}}
+	json.Unmarshal([]byte(row.CGPAMentions), &analysis.CGPAMentions)
+	json.Unmarshal([]byte(row.Internships), &analysis.Internships)
+	err := db.Raw(`
+		SELECT skill, category, mentions FROM resume_skill
+		WHERE student_id = ?
+		ORDER BY mentions DESC, skill`, srn).Scan(&analysis.Skills).Error
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	response := map[string]interface{}{
+		"srn":           srn,
+		"indexed_at":    row.IndexedAt,
+		"skills":        analysis.Skills,
+		"cgpa_mentions": analysis.CGPAMentions,
+		"internships":   analysis.Internships,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// FindStudentsByResumeSkill lists the students whose resume mentions a skill.
+// A dictionary skill (or alias) is looked up in the index, anything else is
+// searched for in the resume text.
+func FindStudentsByResumeSkill(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	skill := strings.TrimSpace(r.URL.Query().Get("skill"))
+	if skill == "" {
+		http.Error(w, "Missing skill parameter", http.StatusBadRequest)
+		return
+	}
+	type StudentSkill struct {
+		SRN      string `json:"srn"`
+		Name     string `json:"name"`
+		Mentions int    `json:"mentions,omitempty"`
+	}
+	students := []StudentSkill{}
+
+	canonical, matched := "", "text"
+	for i, entry := range skillDictionary.Skills {
+		if strings.EqualFold(entry.Name, skill) || skillDictionary.patterns[i].MatchString(skill) {
+			canonical, matched = entry.Name, "dictionary"
+			break
+		}
+	}
+	var err error
+	if canonical != "" {
+		err = db.Raw(`
+			SELECT s.student_id AS srn, s.name, rs.mentions
+			FROM resume_skill rs
+			JOIN student s ON s.student_id = rs.student_id
+			WHERE rs.skill = ?
+			ORDER BY rs.mentions DESC, s.student_id`, canonical).Scan(&students).Error
+	} else {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(skill) + "%"
+		err = db.Raw(`
+			SELECT s.student_id AS srn, s.name
+			FROM resume_text rt
+			JOIN student s ON s.student_id = rt.student_id
+			WHERE LOWER(rt.text) LIKE LOWER(?) ESCAPE '\'
+			ORDER BY s.student_id`, pattern).Scan(&students).Error
+	}
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if canonical == "" {
+		canonical = skill
+	}
+	response := map[string]interface{}{
+		"skill":    canonical,
+		"matched":  matched,
+		"students": students,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// reindexResumes indexes every stored resume again, after the dictionary
+// has changed
+func reindexResumes(ctx context.Context, db *gorm.DB) (indexed, failed int, err error) {
+	var rows []struct {
+		StudentID string
+		Resume    string
+	}
+	if err := db.Raw("SELECT student_id, resume FROM student WHERE resume <> '' ORDER BY student_id").Scan(&rows).Error; err != nil {
+		return 0, 0, err
+	}
+	for _, row := range rows {
+		if err := indexStoredResume(ctx, db, row.StudentID, row.Resume); err != nil {
+			log.Printf("Could not index resume of %s: %v", row.StudentID, err)
+			failed++
+			continue
+		}
+		indexed++
+	}
+	return indexed, failed, nil
+}
+
+// indexStudentResume indexes the student's current resume, if they have one
+func indexStudentResume(ctx context.Context, db *gorm.DB, srn string) error {
+	var key string
+	if err := db.Raw("SELECT resume FROM student WHERE student_id = ?", srn).Scan(&key).Error; err != nil {
+		return err
+	}
+	if key == "" {
+		return nil
+	}
+	return indexStoredResume(ctx, db, srn, key)
+}
+
+// reindexCommand implements the reindex-resumes subcommand
+func reindexCommand(cfg Config, args []string) {
+	db := connectDB(cfg.Database)
+	migrateDatabase(db)
+	indexed, failed, err := reindexResumes(context.Background(), db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("indexed %d resumes, %d failed\n", indexed, failed)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestAnalyzeResumeCutsInternshipLinesOnCharacters(t *testing.T) {
+	for pad := 0; pad < 3; pad++ {
+		line := strings.Repeat("x", pad) + "• internship " + strings.Repeat("–", 100)
+		analysis := analyzeResume(line+"\n", &SkillDictionary{})
+		if len(analysis.Internships) != 1 {
+			t.Fatalf("internships = %q", analysis.Internships)
+		}
+		got := analysis.Internships[0]
+		if !utf8.ValidString(got) || len(got) > maxInternshipLine || len(got) < maxInternshipLine-utf8.UTFMax {
+			t.Errorf("pad %d: internship line of %d bytes, valid UTF-8 %v", pad, len(got), utf8.ValidString(got))
+		}
+	}
+}
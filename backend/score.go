@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ScoreWeights sets how much each component counts towards the score. Only
+// the ratios matter, the weights are normalised before use.
+type ScoreWeights struct {
+	CGPA              float64 `json:"cgpa"`
+	LeetCodeProblems  float64 `json:"leetcode_problems"`
+	LeetCodeRanking   float64 `json:"leetcode_ranking"`
+	GithubRepos       float64 `json:"github_repos"`
+	LanguageDiversity float64 `json:"language_diversity"`
+	MentorSessions    float64 `json:"mentor_sessions"`
+}
+
+// ScoreTargets are the values at which a component is considered maxed out
+type ScoreTargets struct {
+	WeightedProblems int `json:"weighted_problems"` // easy + 2*medium + 3*hard
+	RankingCeiling   int `json:"ranking_ceiling"`   // rankings at or past this score 0
+	PinnedRepos      int `json:"pinned_repos"`
+	Languages        int `json:"languages"`
+	MentorSessions   int `json:"mentor_sessions"`
+}
+
+// ScoreConfig is read from score_weights.json. Profiles let a placement cell
+// keep a set of weights per drive, picked with /score?profile=<name>.
+type ScoreConfig struct {
+	Weights  ScoreWeights            `json:"weights"`
+	Targets  ScoreTargets            `json:"targets"`
+	Profiles map[string]ScoreWeights `json:"profiles"`
+}
+
+func defaultScoreConfig() ScoreConfig {
+	return ScoreConfig{
+		Weights: ScoreWeights{
+			CGPA:              0.30,
+			LeetCodeProblems:  0.25,
+			LeetCodeRanking:   0.10,
+			GithubRepos:       0.15,
+			LanguageDiversity: 0.10,
+			MentorSessions:    0.10,
+		},
+		Targets: ScoreTargets{
+			WeightedProblems: 600,
+			RankingCeiling:   1000000,
+			PinnedRepos:      6,
+			Languages:        5,
+			MentorSessions:   4,
+		},
+	}
+}
+
+// scoreConfig is loaded once at startup
+var scoreConfig = defaultScoreConfig()
+
+// loadScoreConfig reads the scoring config, falling back to the defaults for
+// a missing file or any target left at zero
+func loadScoreConfig(path string) (ScoreConfig, error) {
+	cfg := defaultScoreConfig()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	var fileCfg ScoreConfig
+	if err := json.Unmarshal(data, &fileCfg); err != nil {
+		return cfg, fmt.Errorf("could not parse %s: %v", path, err)
+	}
+	if fileCfg.Weights != (ScoreWeights{}) {
+		cfg.Weights = fileCfg.Weights
+	}
+	if fileCfg.Targets.WeightedProblems > 0 {
+		cfg.Targets.WeightedProblems = fileCfg.Targets.WeightedProblems
+	}
+	if fileCfg.Targets.RankingCeiling > 1 {
+		cfg.Targets.RankingCeiling = fileCfg.Targets.RankingCeiling
+	}
+	if fileCfg.Targets.PinnedRepos > 0 {
+		cfg.Targets.PinnedRepos = fileCfg.Targets.PinnedRepos
+	}
+	if fileCfg.Targets.Languages > 0 {
+		cfg.Targets.Languages = fileCfg.Targets.Languages
+	}
+	if fileCfg.Targets.MentorSessions > 0 {
+		cfg.Targets.MentorSessions = fileCfg.Targets.MentorSessions
+	}
+	cfg.Profiles = fileCfg.Profiles
+	for name, w := range cfg.Profiles {
+		if totalWeight(w) <= 0 {
+			return cfg, fmt.Errorf("score profile %q has no positive weights", name)
+		}
+	}
+	if totalWeight(cfg.Weights) <= 0 {
+		return cfg, errors.New("score weights must not all be zero")
+	}
+	return cfg, nil
+}
+
+func totalWeight(w ScoreWeights) float64 {
+	total := 0.0
+	for _, v := range []float64{w.CGPA, w.LeetCodeProblems, w.LeetCodeRanking, w.GithubRepos, w.LanguageDiversity, w.MentorSessions} {
+		if v < 0 {
+			return -1
+		}
+		total += v
+	}
+	return total
+}
+
+// ScoreInputs is the raw student data that goes into the score
+type ScoreInputs struct {
+	CGPA           float64
+	EasySolved     int
+	MediumSolved   int
+	HardSolved     int
+	Ranking        int
+	PinnedRepos    int
+	Languages      []string
+	MentorSessions int
+}
+
+// ScoreComponent is one line of the score breakdown
+type ScoreComponent struct {
+	Name       string  `json:"name"`
+	Value      float64 `json:"value"`
+	Normalized float64 `json:"normalized"`
+	Weight     float64 `json:"weight"`
+	Points     float64 `json:"points"`
+}
+
+// ScoreResult is the composite score on a 0-10 scale with its breakdown
+type ScoreResult struct {
+	Score      float64          `json:"score"`
+	Components []ScoreComponent `json:"components"`
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// computeScore turns the inputs into a 0-10 score. Every component is first
+// normalised to 0-1 against its target, then weighted.
+func computeScore(in ScoreInputs, weights ScoreWeights, targets ScoreTargets) ScoreResult {
+	weightedProblems := in.EasySolved + 2*in.MediumSolved + 3*in.HardSolved
+	rankingScore := 0.0
+	if in.Ranking > 0 {
+		rankingScore = clamp01(1 - math.Log(float64(in.Ranking))/math.Log(float64(targets.RankingCeiling)))
+	}
+
+	components := []ScoreComponent{
+		{Name: "cgpa", Value: in.CGPA, Normalized: clamp01(in.CGPA / 10), Weight: weights.CGPA},
+		{Name: "leetcode_problems", Value: float64(weightedProblems), Normalized: clamp01(float64(weightedProblems) / float64(targets.WeightedProblems)), Weight: weights.LeetCodeProblems},
+		{Name: "leetcode_ranking", Value: float64(in.Ranking), Normalized: rankingScore, Weight: weights.LeetCodeRanking},
+		{Name: "github_repos", Value: float64(in.PinnedRepos), Normalized: clamp01(float64(in.PinnedRepos) / float64(targets.PinnedRepos)), Weight: weights.GithubRepos},
+		{Name: "language_diversity", Value: float64(len(in.Languages)), Normalized: clamp01(float64(len(in.Languages)) / float64(targets.Languages)), Weight: weights.LanguageDiversity},
+		{Name: "mentor_sessions", Value: float64(in.MentorSessions), Normalized: clamp01(float64(in.MentorSessions) / float64(targets.MentorSessions)), Weight: weights.MentorSessions},
+	}
+
+	total := totalWeight(weights)
+	result := ScoreResult{}
+	for i := range components {
+		c := &components[i]
+		c.Weight = c.Weight / total
+		c.Points = 10 * c.Weight * c.Normalized
+		result.Score += c.Points
+
+		c.Normalized = round2(c.Normalized)
+		c.Weight = round2(c.Weight)
+		c.Points = round2(c.Points)
+	}
+	result.Score = round2(result.Score)
+	result.Components = components
+	return result
+}
+
+// fetchScoreInputs gathers everything the score needs for one student. found
+// is false when the student does not exist.
+func fetchScoreInputs(db *gorm.DB, srn string) (in ScoreInputs, found bool, err error) {
+	res := db.Raw("SELECT cgpa FROM student WHERE student_id = ?", srn).Scan(&in.CGPA)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return in, false, res.Error
+	}
+
+	var leetcode struct {
+		Ranking  int
+		NoEasy   int
+		NoMedium int
+		NoHard   int
+	}
+	err = db.Raw(`
+		SELECT l.ranking, COALESCE(p.no_easy, 0) AS no_easy, COALESCE(p.no_medium, 0) AS no_medium, COALESCE(p.no_hard, 0) AS no_hard
+		FROM leetcode l
+		LEFT JOIN problems p ON l.leetcode_id = p.leetcode_id
+		WHERE l.student_id = ?`, srn).Scan(&leetcode).Error
+	if err != nil {
+		return in, true, err
+	}
+	in.Ranking = leetcode.Ranking
+	in.EasySolved, in.MediumSolved, in.HardSolved = leetcode.NoEasy, leetcode.NoMedium, leetcode.NoHard
+
+	var repoLanguages []string
+	err = db.Raw(`
+		SELECT r.language
+		FROM github g
+		JOIN repository r ON g.github_id = r.github_id
+		WHERE g.student_id = ?`, srn).Scan(&repoLanguages).Error
+	if err != nil {
+		return in, true, err
+	}
+	in.PinnedRepos = len(repoLanguages)
+	seen := make(map[string]bool)
+	for _, langs := range repoLanguages {
+		for _, lang := range strings.Split(langs, ",") {
+			lang = strings.TrimSpace(lang)
+			if lang != "" && !seen[lang] {
+				seen[lang] = true
+				in.Languages = append(in.Languages, lang)
+			}
+		}
+	}
+
+	err = db.Raw("SELECT COUNT(*) FROM mentor_sessions WHERE student_id = ?", srn).Scan(&in.MentorSessions).Error
+	return in, true, err
+}
+
+// GetScore returns the placement readiness score of a student
+func GetScore(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn := r.URL.Query().Get("srn")
+	if srn == "" {
+		http.Error(w, "Missing srn parameter", http.StatusBadRequest)
+		return
+	}
+	profile := r.URL.Query().Get("profile")
+	weights := scoreConfig.Weights
+	if profile != "" {
+		profileWeights, ok := scoreConfig.Profiles[profile]
+		if !ok {
+			http.Error(w, "Unknown score profile", http.StatusBadRequest)
+			return
+		}
+		weights = profileWeights
+	} else {
+		profile = "default"
+	}
+
+	in, found, err := fetchScoreInputs(db, srn)
+	if err != nil {
+		http.Error(w, "Failed to query database", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Student not found", http.StatusNotFound)
+		return
+	}
+
+	result := computeScore(in, weights, scoreConfig.Targets)
+	response := map[string]interface{}{
+		"srn":        srn,
+		"profile":    profile,
+		"score":      result.Score,
+		"components": result.Components,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
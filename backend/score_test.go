@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComputeScoreNormalisesWeights(t *testing.T) {
+	targets := defaultScoreConfig().Targets
+	in := ScoreInputs{CGPA: 10, EasySolved: 30, MediumSolved: 15}
+
+	got := computeScore(in, ScoreWeights{CGPA: 3, LeetCodeProblems: 1}, targets)
+	// cgpa is maxed out, 60 of 600 weighted problems is 0.1
+	if got.Score != 7.75 {
+		t.Errorf("score = %v, want 7.75", got.Score)
+	}
+	weights := map[string]float64{}
+	for _, c := range got.Components {
+		weights[c.Name] = c.Weight
+	}
+	if weights["cgpa"] != 0.75 || weights["leetcode_problems"] != 0.25 || weights["github_repos"] != 0 {
+		t.Errorf("normalised weights = %v", weights)
+	}
+	if scaled := computeScore(in, ScoreWeights{CGPA: 30, LeetCodeProblems: 10}, targets); scaled.Score != got.Score {
+		t.Errorf("score with scaled weights = %v, want %v", scaled.Score, got.Score)
+	}
+}
+
+func TestComputeScoreComponentBounds(t *testing.T) {
+	cfg := defaultScoreConfig()
+	tests := []struct {
+		name string
+		in   ScoreInputs
+		want float64
+	}{
+		{"nothing", ScoreInputs{}, 0},
+		{"everything past the targets", ScoreInputs{
+			CGPA: 10, HardSolved: 1000, Ranking: 1, PinnedRepos: 10,
+			Languages: []string{"Go", "C", "Java", "Python", "Rust", "Zig"}, MentorSessions: 9,
+		}, 10},
+		{"ranking at the ceiling", ScoreInputs{Ranking: cfg.Targets.RankingCeiling}, 0},
+		{"ranking past the ceiling", ScoreInputs{Ranking: 10 * cfg.Targets.RankingCeiling}, 0},
+	}
+	for _, tt := range tests {
+		if got := computeScore(tt.in, cfg.Weights, cfg.Targets); got.Score != tt.want {
+			t.Errorf("%s: score = %v, want %v", tt.name, got.Score, tt.want)
+		}
+	}
+}
+
+func TestLoadScoreConfig(t *testing.T) {
+	tests := []struct {
+		name, content string
+		wantErr       string
+	}{
+		{"missing file", "", ""},
+		{"zero targets keep the defaults", `{"weights": {"cgpa": 1}, "targets": {"languages": 0}}`, ""},
+		{"invalid json", `{"weights": `, "could not parse"},
+		{"negative weight", `{"weights": {"cgpa": 1, "github_repos": -1}}`, "must not all be zero"},
+		{"empty profile", `{"profiles": {"core": {}}}`, `profile "core"`},
+		{"negative profile weight", `{"profiles": {"core": {"cgpa": 2, "mentor_sessions": -1}}}`, `profile "core"`},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "score_weights.json")
+		if tt.content != "" {
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cfg, err := loadScoreConfig(path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if cfg.Targets != defaultScoreConfig().Targets {
+			t.Errorf("%s: targets = %+v, want the defaults", tt.name, cfg.Targets)
+		}
+	}
+
+	cfg, err := loadScoreConfig("score_weights.json")
+	if err != nil || len(cfg.Profiles) != 2 || cfg.Profiles["core"].CGPA != 0.5 {
+		t.Errorf("score_weights.json = %+v, %v", cfg, err)
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newSQLiteDB opens a migrated SQLite database in a temporary directory
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := openDB(DatabaseConfig{Driver: "sqlite", Path: filepath.Join(t.TempDir(), "placify.db")})
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	migrations, err := embeddedMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrateUp(db, migrations, 0); err != nil {
+		t.Fatalf("migrateUp: %v", err)
+	}
+	return db
+}
+
+// seedSQLiteDB adds mentors 1 and 2 and students PES1 to PES3 through the
+// importer's insert functions. PES1 and PES3 share the top CGPA.
+func seedSQLiteDB(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	must(db.Exec("INSERT INTO mentor (mentor_id, mentor_name) VALUES (1, 'Asha Rao'), (2, 'Vikram Iyer')").Error)
+	dob := time.Date(2004, 6, 1, 0, 0, 0, 0, time.UTC)
+	must(insertStudent(db, Student{StudentID: "PES1", Name: "Anu", Dob: dob, Sem: 5, MentorID: 1, CGPA: 9.1}))
+	must(insertStudent(db, Student{StudentID: "PES2", Name: "Bala", Dob: dob, Sem: 5, MentorID: 1, CGPA: 8.2}))
+	must(insertStudent(db, Student{StudentID: "PES3", Name: "Chitra", Dob: dob, Sem: 7, MentorID: 2, CGPA: 9.1}))
+	must(insertGithub(db, Github{GithubID: "https://github.com/anu", StudentID: "PES1", Username: "anu"}))
+	must(insertRepository(db, Repository{RepoID: "https://github.com/anu/placify", GithubID: "https://github.com/anu",
+		RepoName: "placify", Language: "Go"}))
+	must(insertLeetCode(db, LeetCode{LeetCodeID: "https://leetcode.com/anu", StudentID: "PES1", Username: "anu", Rank: 1200}))
+	must(insertProblems(db, Problems{LeetcodeID: "https://leetcode.com/anu", NoEasy: 50, NoMedium: 30, NoHard: 5}))
+	must(insertMentorSessions(db, Mentor_Session_DB{MentorID: 1, StudentID: "PES1",
+		Date: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), Advice: "Update resume"}))
+}
+
+func TestSQLiteLeaderboard(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	initSessionSecret("handler-test-secret")
+	r := mux.NewRouter()
+	r.HandleFunc("/leaderboard", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		GetLeaderboard(db, w, r)
+	}))
+
+	var body struct {
+		Total   int
+		Entries []LeaderboardEntry
+		Me      *LeaderboardPosition
+	}
+	rec := serveRequest(r, "GET", "/leaderboard", testToken(t, "PES2", roleStudent), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /leaderboard = %d %s", rec.Code, rec.Body)
+	}
+	decodeResponse(t, rec, &body)
+	if body.Total != 3 || body.Entries[0].SRN != "PES1" || body.Entries[1].SRN != "PES3" || body.Entries[1].Rank != 1 {
+		t.Errorf("cgpa leaderboard = %+v", body.Entries)
+	}
+	if body.Me == nil || body.Me.Rank != 2 || body.Me.Percentile != 0 {
+		t.Errorf("me = %+v, want rank 2 at percentile 0", body.Me)
+	}
+
+	rec = serveRequest(r, "GET", "/leaderboard?metric=total_solved&sem=5", testToken(t, "PES1", roleStudent), "")
+	decodeResponse(t, rec, &body)
+	if body.Total != 2 || body.Entries[0].Value != 85 || body.Me == nil || body.Me.Percentile != 100 {
+		t.Errorf("total_solved leaderboard = %+v, me %+v", body.Entries, body.Me)
+	}
+}
+
+func TestSQLiteStoreSessions(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	store := newSQLStore(db)
+
+	id, err := store.CreateMentorSession(MentorSessionJSON{SRN: "PES1", MentorID: 1, Date: "2023-12-20", Advice: "Practice graphs"})
+	if err != nil {
+		t.Fatalf("CreateMentorSession: %v", err)
+	}
+	sessions, err := store.MentorSessions(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].SessionID != id || sessions[0].Date != "2023-12-20" || sessions[1].Date != "2024-01-05" {
+		t.Errorf("MentorSessions = %+v", sessions)
+	}
+
+	if err := store.UpdateMentorSession(MentorSessionJSON{SessionID: id, Date: "2024-02-01", Advice: "Mock interview"}); err != nil {
+		t.Fatal(err)
+	}
+	session, found, err := store.MentorSession(id)
+	if err != nil || !found || session.Date != "2024-02-01" || session.Advice != "Mock interview" {
+		t.Errorf("MentorSession = %+v, %v, %v", session, found, err)
+	}
+	if deleted, err := store.DeleteMentorSession(id); err != nil || !deleted {
+		t.Errorf("DeleteMentorSession = %v, %v", deleted, err)
+	}
+}
+
+func TestSQLiteCascadingDeletes(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+
+	// The schema cascades from a student to their profiles
+	if err := db.Exec("DELETE FROM student WHERE student_id = 'PES1'").Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"github", "repository", "leetcode", "problems", "mentor_sessions"} {
+		var n int64
+		if err := db.Raw("SELECT COUNT(*) FROM " + table).Scan(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%s has %d rows left", table, n)
+		}
+	}
+	// and refuses to drop a mentor who still has students
+	quiet := db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	if err := quiet.Exec("DELETE FROM mentor WHERE mentor_id = 2").Error; err == nil {
+		t.Error("deleted a mentor with students")
+	}
+}
+
+func TestSQLiteSoftDeleteAndRestore(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+
+	if _, err := softDeleteStudent(db, "PES1", "mentor:1"); err != nil {
+		t.Fatalf("softDeleteStudent: %v", err)
+	}
+	if exists, _ := rowExists(db, "SELECT 1 FROM student WHERE student_id = ?", "PES1"); exists {
+		t.Fatal("student still exists after deletion")
+	}
+	if err := restoreStudent(db, "PES1", "mentor:1"); err != nil {
+		t.Fatalf("restoreStudent: %v", err)
+	}
+	sessions, err := newSQLStore(db).StudentSessions("PES1")
+	if err != nil || len(sessions) != 1 || sessions[0].Date != "2024-01-05" {
+		t.Errorf("sessions after restore = %+v, %v", sessions, err)
+	}
+}
+
+func TestSQLitePurgeRemovesArchivedResumes(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	saved := resumeStore
+	resumeStore = newLocalFileStore(t.TempDir())
+	t.Cleanup(func() { resumeStore = saved })
+
+	ctx := context.Background()
+	var keys []string
+	for _, data := range []string{"%PDF-1.4 first", "%PDF-1.4 second"} {
+		v, _, err := storeResumeVersion(ctx, db, "PES1", []byte(data), "student:PES1")
+		if err != nil {
+			t.Fatalf("storeResumeVersion: %v", err)
+		}
+		keys = append(keys, v.Key)
+	}
+	if _, err := softDeleteStudent(db, "PES1", "mentor:1"); err != nil {
+		t.Fatalf("softDeleteStudent: %v", err)
+	}
+	if n, err := purgeDeletedStudents(db, time.Now()); err != nil || n != 0 {
+		t.Fatalf("purge within retention = %d, %v", n, err)
+	}
+	for _, key := range keys {
+		if _, err := resumeStore.Open(ctx, key); err != nil {
+			t.Errorf("resume %s gone before the archive expired: %v", key, err)
+		}
+	}
+	if n, err := purgeDeletedStudents(db, time.Now().Add(deletionRetention+time.Hour)); err != nil || n != 1 {
+		t.Fatalf("purge after retention = %d, %v", n, err)
+	}
+	for _, key := range keys {
+		if _, err := resumeStore.Open(ctx, key); !errors.Is(err, errFileNotFound) {
+			t.Errorf("resume %s still stored after purge: %v", key, err)
+		}
+	}
+}
+
+func TestSQLitePurgeKeepsSharedResumes(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	saved := resumeStore
+	resumeStore = newLocalFileStore(t.TempDir())
+	t.Cleanup(func() { resumeStore = saved })
+
+	// Imported students can point at the same file, and older rows hold an
+	// absolute path to it
+	ctx := context.Background()
+	if err := resumeStore.Put(ctx, "PES1_Resume.pdf", strings.NewReader("%PDF-1.4 shared")); err != nil {
+		t.Fatal(err)
+	}
+	err := db.Exec("UPDATE student SET resume = CASE student_id WHEN 'PES2' THEN '/srv/resumes/PES1_Resume.pdf' ELSE 'PES1_Resume.pdf' END").Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := func() bool {
+		_, err := resumeStore.Open(ctx, "PES1_Resume.pdf")
+		return err == nil
+	}
+	expire := func(srn string) {
+		t.Helper()
+		if err := db.Exec("UPDATE deleted_students SET restorable_until = ? WHERE student_id = ?", time.Now().Add(-time.Hour), srn).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n, err := purgeDeletedStudents(db, time.Now()); err != nil || n != 1 {
+			t.Fatalf("purge of %s = %d, %v", srn, n, err)
+		}
+	}
+	for _, srn := range []string{"PES1", "PES2", "PES3"} {
+		if _, err := softDeleteStudent(db, srn, "mentor:1"); err != nil {
+			t.Fatalf("softDeleteStudent %s: %v", srn, err)
+		}
+		if srn == "PES1" {
+			expire(srn)
+			if !stored() {
+				t.Fatal("purging PES1 removed the resume PES2 and PES3 still use")
+			}
+		}
+	}
+	expire("PES2")
+	if !stored() {
+		t.Fatal("purging PES2 removed the resume the archive of PES3 still uses")
+	}
+	expire("PES3")
+	if stored() {
+		t.Error("resume still stored after every student using it was purged")
+	}
+}
+
+func TestSQLiteConcurrentResumeUploadsKeepEveryVersion(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	saved := resumeStore
+	resumeStore = newLocalFileStore(t.TempDir())
+	t.Cleanup(func() { resumeStore = saved })
+
+	const uploads = 8
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, uploads)
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := storeResumeVersion(ctx, db, "PES1", []byte(fmt.Sprintf("%%PDF-1.4 upload %d", i)), "student:PES1")
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("storeResumeVersion: %v", err)
+		}
+	}
+	versions, err := fetchResumeVersions(db, "PES1")
+	if err != nil || len(versions) != uploads {
+		t.Fatalf("versions = %d, %v, want %d", len(versions), err, uploads)
+	}
+	var older []byte // the content of a version that is not current
+	for i, v := range versions {
+		if v.Version != uploads-i {
+			t.Errorf("versions[%d] = %d, want %d", i, v.Version, uploads-i)
+		}
+		f, err := resumeStore.Open(ctx, v.Key)
+		if err != nil {
+			t.Errorf("version %d: %v", v.Version, err)
+			continue
+		}
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != v.SHA256 {
+			t.Errorf("version %d file does not match its hash", v.Version)
+		}
+		if !v.Current {
+			older = data
+		}
+	}
+	if _, created, err := storeResumeVersion(ctx, db, "PES1", older, "student:PES1"); err != nil || !created {
+		t.Errorf("re-upload of an older version = %v, %v, want a new version", created, err)
+	}
+}
+
+func TestSQLiteResumeTextSearchMatchesWildcardsLiterally(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	for srn, text := range map[string]string{"PES1": "scored 100% in qq_zz", "PES2": "scored 100 in qqxzz"} {
+		if err := db.Exec("INSERT INTO resume_text (student_id, text, indexed_at) VALUES (?, ?, ?)", srn, text, time.Now()).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, skill := range []string{"qq_zz", "100%"} {
+		rec := httptest.NewRecorder()
+		FindStudentsByResumeSkill(db, rec, httptest.NewRequest("GET", "/students/byResumeSkill?skill="+url.QueryEscape(skill), nil))
+		var resp struct {
+			Students []struct{ SRN string }
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: %d %v", skill, rec.Code, err)
+		}
+		if len(resp.Students) != 1 || resp.Students[0].SRN != "PES1" {
+			t.Errorf("search for %q = %+v, want only PES1", skill, resp.Students)
+		}
+	}
+}
+
+func TestSQLiteStudentAcceptsOneOffer(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	seedOffers(t, db)
+
+	errs := make(chan error, 2)
+	for id := int64(1); id <= 2; id++ {
+		go func(id int64) {
+			app := Application{ApplicationID: id, StudentID: "PES1", Stage: stageOffer}
+			errs <- moveApplication(db, app, stageChange{Stage: stageAccepted}, "student:PES1")
+		}(id)
+	}
+	var accepted, refused int
+	for i := 0; i < 2; i++ {
+		switch err := <-errs; {
+		case err == nil:
+			accepted++
+		case errors.Is(err, errAlreadyPlaced):
+			refused++
+		default:
+			t.Errorf("moveApplication: %v", err)
+		}
+	}
+	if accepted != 1 || refused != 1 {
+		t.Errorf("%d accepted and %d refused, want one of each", accepted, refused)
+	}
+	// The index holds even for writes that skip moveApplication's check
+	quiet := db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
+	err := quiet.Exec("UPDATE application SET stage = 'accepted' WHERE student_id = 'PES1' AND stage <> 'accepted'").Error
+	if !isUniqueViolation(err) {
+		t.Errorf("second accepted application = %v, want a unique violation", err)
+	}
+}
+
+func TestSQLiteMigrateDownAndUp(t *testing.T) {
+	db := newSQLiteDB(t)
+	migrations, err := embeddedMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, err := migrateDown(db, migrations, len(migrations))
+	if err != nil || len(done) != len(migrations) {
+		t.Fatalf("migrateDown reverted %d of %d: %v", len(done), len(migrations), err)
+	}
+	if done, err := migrateUp(db, migrations, 0); err != nil || len(done) != len(migrations) {
+		t.Fatalf("migrateUp applied %d of %d: %v", len(done), len(migrations), err)
+	}
+}
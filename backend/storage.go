@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileStore keeps uploaded files such as resumes. Keys are plain file names.
+type FileStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+var errFileNotFound = errors.New("file not found")
+
+// resumeStore holds the resume PDFs, in the resume_dir of the config
+var resumeStore FileStore = newLocalFileStore(defaultConfig().ResumeDir)
+
+// localFileStore keeps files in one directory on local disk
+type localFileStore struct {
+	dir string
+}
+
+func newLocalFileStore(dir string) *localFileStore {
+	return &localFileStore{dir: dir}
+}
+
+// path maps a key into the store's directory. Only the last element of the
+// key is used, so a key cannot escape the directory, and the absolute paths
+// stored for older students resolve to the same file.
+func (s *localFileStore) path(key string) (string, error) {
+	name := filepath.Base(key)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		return "", fmt.Errorf("invalid file key %q", key)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Put writes to a temporary file first, so a reader never sees half a file
+func (s *localFileStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("could not create %s: %v", s.dir, err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("could not create file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("could not store file: %v", err)
+	}
+	return nil
+}
+
+func (s *localFileStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", errFileNotFound, key)
+	}
+	return f, err
+}
+
+func (s *localFileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
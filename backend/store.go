@@ -0,0 +1,223 @@
+package main
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Store is the data the student, GitHub, LeetCode, mentor and mentor session
+// handlers read and write. sqlStore is used by the server, memoryStore
+// lets the handlers be tested without a database. Lookups of a single row
+// report whether it exists instead of returning an error.
+type Store interface {
+	Student(srn string) (Student, bool, error)
+	StudentCredential(srn string) (StudentCredential, bool, error)
+	MentorStudents(mentorID int) ([]Student, error)
+
+	// StudentRepositories lists the repositories of the student's GitHub
+	// profile ordered by repo_id, RepoLanguages the languages of the given
+	// repositories largest first.
+	StudentRepositories(srn string) ([]Repository, error)
+	RepoLanguages(repoIDs []string) ([]RepoLanguage, error)
+
+	StudentLeetCode(srn string) (LeetCode, Problems, bool, error)
+
+	Mentor(mentorID int) (Mentor, bool, error)
+	MentorCredential(mentorID int) (MentorCredential, bool, error)
+
+	// StudentSessions lists the sessions of one student and MentorSessions
+	// those of a mentor's students, of only srn when it is not empty. Both
+	// are ordered by date and session_id.
+	StudentSessions(srn string) ([]MentorSessionJSON, error)
+	MentorSessions(mentorID int, srn string) ([]MentorSessionJSON, error)
+	MentorSession(sessionID int64) (MentorSessionJSON, bool, error)
+	CreateMentorSession(session MentorSessionJSON) (int64, error)
+	UpdateMentorSession(session MentorSessionJSON) error
+	DeleteMentorSession(sessionID int64) (bool, error)
+}
+
+// sqlStore runs the Store queries against the Postgres or SQLite database
+type sqlStore struct {
+	db *gorm.DB
+}
+
+func newSQLStore(db *gorm.DB) *sqlStore {
+	return &sqlStore{db: db}
+}
+
+// first scans the single row of query into dest
+func (s *sqlStore) first(dest interface{}, query string, args ...interface{}) (bool, error) {
+	res := s.db.Raw(query, args...).Scan(dest)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func (s *sqlStore) Student(srn string) (Student, bool, error) {
+	var student Student
+	found, err := s.first(&student, `
+		SELECT student_id, name, phone_no, dob, gender, resume, sem, mentor_id, cgpa, email, age, linkedin, degree, stream
+		FROM student
+		WHERE student_id = ?`, srn)
+	return student, found, err
+}
+
+func (s *sqlStore) StudentCredential(srn string) (StudentCredential, bool, error) {
+	var cred StudentCredential
+	found, err := s.first(&cred, "SELECT student_id, password_hash, updated_at FROM student_credentials WHERE student_id = ?", srn)
+	return cred, found, err
+}
+
+func (s *sqlStore) MentorStudents(mentorID int) ([]Student, error) {
+	students := []Student{}
+	err := s.db.Raw(`
+		SELECT student_id, name, sem, mentor_id, cgpa, degree, stream, email
+		FROM student
+		WHERE mentor_id = ?
+		ORDER BY student_id`, mentorID).Scan(&students).Error
+	return students, err
+}
+
+func (s *sqlStore) StudentRepositories(srn string) ([]Repository, error) {
+	var repos []Repository
+	err := s.db.Raw(`
+		SELECT r.repo_id, r.github_id, r.repo_name, r.language, r.description
+		FROM github g
+		JOIN repository r ON g.github_id = r.github_id
+		WHERE g.student_id = ?
+		ORDER BY r.repo_id`, srn).Scan(&repos).Error
+	return repos, err
+}
+
+func (s *sqlStore) RepoLanguages(repoIDs []string) ([]RepoLanguage, error) {
+	var languages []RepoLanguage
+	if len(repoIDs) == 0 {
+		return languages, nil
+	}
+	err := s.db.Raw(`
+		SELECT repo_id, language, bytes, percentage
+		FROM repo_language
+		WHERE repo_id IN ?
+		ORDER BY bytes DESC, language`, repoIDs).Scan(&languages).Error
+	return languages, err
+}
+
+func (s *sqlStore) StudentLeetCode(srn string) (LeetCode, Problems, bool, error) {
+	var row struct {
+		LeetCode
+		ProblemID int
+		NoEasy    int
+		NoMedium  int
+		NoHard    int
+	}
+	found, err := s.first(&row, `
+		SELECT l.leetcode_id, l.student_id, l.username, l.ranking, p.problem_id, p.no_easy, p.no_medium, p.no_hard
+		FROM leetcode l
+		JOIN problems p ON l.leetcode_id = p.leetcode_id
+		WHERE l.student_id = ?`, srn)
+	problems := Problems{
+		ProblemID:  row.ProblemID,
+		LeetcodeID: row.LeetCodeID,
+		NoEasy:     row.NoEasy,
+		NoMedium:   row.NoMedium,
+		NoHard:     row.NoHard,
+	}
+	return row.LeetCode, problems, found, err
+}
+
+func (s *sqlStore) Mentor(mentorID int) (Mentor, bool, error) {
+	var mentor Mentor
+	found, err := s.first(&mentor, "SELECT mentor_id, mentor_name FROM mentor WHERE mentor_id = ?", mentorID)
+	return mentor, found, err
+}
+
+func (s *sqlStore) MentorCredential(mentorID int) (MentorCredential, bool, error) {
+	var cred MentorCredential
+	found, err := s.first(&cred, "SELECT mentor_id, password_hash, updated_at FROM mentor_credentials WHERE mentor_id = ?", mentorID)
+	return cred, found, err
+}
+
+// dateText formats a DATE column as YYYY-MM-DD in the dialect of db
+func dateText(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == "sqlite" {
+		return "strftime('%Y-%m-%d', " + column + ")"
+	}
+	return "to_char(" + column + ", 'YYYY-MM-DD')"
+}
+
+// forUpdate locks the selected rows until the transaction ends. SQLite has no
+// row locks, there a transaction holds the write lock from its start.
+func forUpdate(db *gorm.DB) string {
+	if db.Dialector.Name() == "sqlite" {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+func (s *sqlStore) sessionColumns() string {
+	return "ms.session_id, ms.student_id AS srn, ms.mentor_id, " + dateText(s.db, "ms.date") + " AS date, ms.advice"
+}
+
+func (s *sqlStore) StudentSessions(srn string) ([]MentorSessionJSON, error) {
+	sessions := []MentorSessionJSON{}
+	err := s.db.Raw(`
+		SELECT `+s.sessionColumns()+`
+		FROM mentor_sessions ms
+		WHERE ms.student_id = ?
+		ORDER BY ms.date, ms.session_id`, srn).Scan(&sessions).Error
+	return sessions, err
+}
+
+func (s *sqlStore) MentorSessions(mentorID int, srn string) ([]MentorSessionJSON, error) {
+	query := `
+		SELECT ` + s.sessionColumns() + `
+		FROM mentor_sessions ms
+		JOIN student s ON s.student_id = ms.student_id
+		WHERE s.mentor_id = ?`
+	args := []interface{}{mentorID}
+	if srn != "" {
+		query += " AND ms.student_id = ?"
+		args = append(args, srn)
+	}
+	query += " ORDER BY ms.date, ms.session_id"
+
+	sessions := []MentorSessionJSON{}
+	err := s.db.Raw(query, args...).Scan(&sessions).Error
+	return sessions, err
+}
+
+func (s *sqlStore) MentorSession(sessionID int64) (MentorSessionJSON, bool, error) {
+	var session MentorSessionJSON
+	found, err := s.first(&session, "SELECT "+s.sessionColumns()+" FROM mentor_sessions ms WHERE ms.session_id = ?", sessionID)
+	return session, found, err
+}
+
+// CreateMentorSession stores the date as a time.Time like the importer does,
+// so SQLite, which keeps dates as text, has them all in one format
+func (s *sqlStore) CreateMentorSession(session MentorSessionJSON) (int64, error) {
+	date, err := time.Parse(sessionDateLayout, session.Date)
+	if err != nil {
+		return 0, err
+	}
+	var sessionID int64
+	err = s.db.Raw(`INSERT INTO mentor_sessions (mentor_id, student_id, date, advice)
+		VALUES ($1, $2, $3, $4)
+		RETURNING session_id`, session.MentorID, session.SRN, date, session.Advice).Scan(&sessionID).Error
+	return sessionID, err
+}
+
+func (s *sqlStore) UpdateMentorSession(session MentorSessionJSON) error {
+	date, err := time.Parse(sessionDateLayout, session.Date)
+	if err != nil {
+		return err
+	}
+	return s.db.Exec("UPDATE mentor_sessions SET date = ?, advice = ? WHERE session_id = ?",
+		date, session.Advice, session.SessionID).Error
+}
+
+func (s *sqlStore) DeleteMentorSession(sessionID int64) (bool, error) {
+	res := s.db.Exec("DELETE FROM mentor_sessions WHERE session_id = ?", sessionID)
+	return res.RowsAffected > 0, res.Error
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// memoryStore keeps the Store data in maps. It is used by the handler tests
+// and is filled in through its fields, the same way the importer fills the
+// database.
+type memoryStore struct {
+	mu           sync.Mutex
+	students     map[string]Student
+	studentCreds map[string]StudentCredential
+	mentors      map[int]Mentor
+	mentorCreds  map[int]MentorCredential
+	github       map[string]Github // by github_id
+	repositories map[string]Repository
+	languages    []RepoLanguage
+	leetcode     map[string]LeetCode // by leetcode_id
+	problems     map[string]Problems // by leetcode_id
+	sessions     map[int64]MentorSessionJSON
+	nextSession  int64
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		students:     map[string]Student{},
+		studentCreds: map[string]StudentCredential{},
+		mentors:      map[int]Mentor{},
+		mentorCreds:  map[int]MentorCredential{},
+		github:       map[string]Github{},
+		repositories: map[string]Repository{},
+		leetcode:     map[string]LeetCode{},
+		problems:     map[string]Problems{},
+		sessions:     map[int64]MentorSessionJSON{},
+	}
+}
+
+func (m *memoryStore) Student(srn string) (Student, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	student, ok := m.students[srn]
+	return student, ok, nil
+}
+
+func (m *memoryStore) StudentCredential(srn string) (StudentCredential, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cred, ok := m.studentCreds[srn]
+	return cred, ok, nil
+}
+
+func (m *memoryStore) MentorStudents(mentorID int) ([]Student, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	students := []Student{}
+	for _, student := range m.students {
+		if student.MentorID == mentorID {
+			students = append(students, student)
+		}
+	}
+	sort.Slice(students, func(i, j int) bool { return students[i].StudentID < students[j].StudentID })
+	return students, nil
+}
+
+func (m *memoryStore) StudentRepositories(srn string) ([]Repository, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var repos []Repository
+	for _, repo := range m.repositories {
+		if g, ok := m.github[repo.GithubID]; ok && g.StudentID == srn {
+			repos = append(repos, repo)
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].RepoID < repos[j].RepoID })
+	return repos, nil
+}
+
+func (m *memoryStore) RepoLanguages(repoIDs []string) ([]RepoLanguage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wanted := map[string]bool{}
+	for _, id := range repoIDs {
+		wanted[id] = true
+	}
+	var languages []RepoLanguage
+	for _, lang := range m.languages {
+		if wanted[lang.RepoID] {
+			languages = append(languages, lang)
+		}
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Bytes != languages[j].Bytes {
+			return languages[i].Bytes > languages[j].Bytes
+		}
+		return languages[i].Language < languages[j].Language
+	})
+	return languages, nil
+}
+
+func (m *memoryStore) StudentLeetCode(srn string) (LeetCode, Problems, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, l := range m.leetcode {
+		if l.StudentID != srn {
+			continue
+		}
+		if p, ok := m.problems[l.LeetCodeID]; ok {
+			return l, p, true, nil
+		}
+	}
+	return LeetCode{}, Problems{}, false, nil
+}
+
+func (m *memoryStore) Mentor(mentorID int) (Mentor, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mentor, ok := m.mentors[mentorID]
+	return mentor, ok, nil
+}
+
+func (m *memoryStore) MentorCredential(mentorID int) (MentorCredential, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cred, ok := m.mentorCreds[mentorID]
+	return cred, ok, nil
+}
+
+// sortedSessions returns the sessions keep lets through, ordered by date and id
+func (m *memoryStore) sortedSessions(keep func(MentorSessionJSON) bool) []MentorSessionJSON {
+	sessions := []MentorSessionJSON{}
+	for _, session := range m.sessions {
+		if keep(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].Date != sessions[j].Date {
+			return sessions[i].Date < sessions[j].Date
+		}
+		return sessions[i].SessionID < sessions[j].SessionID
+	})
+	return sessions
+}
+
+func (m *memoryStore) StudentSessions(srn string) ([]MentorSessionJSON, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sortedSessions(func(s MentorSessionJSON) bool { return s.SRN == srn }), nil
+}
+
+func (m *memoryStore) MentorSessions(mentorID int, srn string) ([]MentorSessionJSON, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sortedSessions(func(s MentorSessionJSON) bool {
+		return m.students[s.SRN].MentorID == mentorID && (srn == "" || s.SRN == srn)
+	}), nil
+}
+
+func (m *memoryStore) MentorSession(sessionID int64) (MentorSessionJSON, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionID]
+	return session, ok, nil
+}
+
+func (m *memoryStore) CreateMentorSession(session MentorSessionJSON) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextSession++
+	session.SessionID = m.nextSession
+	m.sessions[session.SessionID] = session
+	return session.SessionID, nil
+}
+
+func (m *memoryStore) UpdateMentorSession(session MentorSessionJSON) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[session.SessionID]; ok {
+		m.sessions[session.SessionID] = session
+	}
+	return nil
+}
+
+func (m *memoryStore) DeleteMentorSession(sessionID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.sessions[sessionID]
+	delete(m.sessions, sessionID)
+	return ok, nil
+}
@@ -0,0 +1,734 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// fieldError is a validation failure of one request field
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// apiError is the JSON body of every error returned by the student API
+type apiError struct {
+	Error  string       `json:"error"`
+	Step   string       `json:"step,omitempty"`
+	Fields []fieldError `json:"fields,omitempty"`
+}
+
+func writeAPIError(w http.ResponseWriter, status int, body apiError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// studentPayload is the JSON body of POST /students
+type studentPayload struct {
+	Name        string   `json:"name"`
+	SRN         string   `json:"srn"`
+	CGPA        *float64 `json:"cgpa"`
+	Sem         *int     `json:"sem"`
+	Age         *int     `json:"age"`
+	DOB         string   `json:"dob"`
+	Email       string   `json:"email"`
+	Phone       string   `json:"phone"`
+	Degree      string   `json:"degree"`
+	Stream      string   `json:"stream"`
+	Gender      string   `json:"gender"`
+	GithubURL   string   `json:"github_url"`
+	LeetcodeURL string   `json:"leetcode_url"`
+	LinkedinURL string   `json:"linkedin_url"`
+	ResumeURL   string   `json:"resume_url"`
+	Password    string   `json:"password"`
+}
+
+var (
+	srnPattern      = regexp.MustCompile(`^PES[12](UG|PG)[0-9]{2}[A-Z]{2}[0-9]{3}$`)
+	phonePattern    = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+	githubUser      = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9]|-[A-Za-z0-9]){0,38}$`)
+	leetcodeUser    = regexp.MustCompile(`^[A-Za-z0-9_-]{1,30}$`)
+	linkedinProfile = regexp.MustCompile(`^[A-Za-z0-9_%-]{3,100}$`)
+)
+
+// profileURL checks that raw is an https URL on host whose path is exactly
+// prefix followed by one path segment accepted by user, and returns that segment
+func profileURL(raw string, hosts []string, prefix string, user *regexp.Regexp) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.RawQuery != "" || u.Fragment != "" {
+		return "", false
+	}
+	hostOK := false
+	for _, h := range hosts {
+		if strings.EqualFold(u.Host, h) {
+			hostOK = true
+		}
+	}
+	if !hostOK {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSuffix(u.Path, "/"), prefix)
+	if !ok || !user.MatchString(rest) {
+		return "", false
+	}
+	return rest, true
+}
+
+var (
+	githubHosts   = []string{"github.com", "www.github.com"}
+	leetcodeHosts = []string{"leetcode.com", "www.leetcode.com"}
+	linkedinHosts = []string{"linkedin.com", "www.linkedin.com"}
+)
+
+// canonicalGithubURL returns https://github.com/<user> for a GitHub profile link
+func canonicalGithubURL(raw string) (string, bool) {
+	user, ok := profileURL(raw, githubHosts, "/", githubUser)
+	if !ok {
+		return "", false
+	}
+	return "https://github.com/" + user, true
+}
+
+// canonicalLeetcodeURL accepts both leetcode.com/<user> and leetcode.com/u/<user>
+// and returns https://leetcode.com/<user>
+func canonicalLeetcodeURL(raw string) (string, bool) {
+	user, ok := profileURL(raw, leetcodeHosts, "/u/", leetcodeUser)
+	if !ok {
+		user, ok = profileURL(raw, leetcodeHosts, "/", leetcodeUser)
+	}
+	if !ok {
+		return "", false
+	}
+	return "https://leetcode.com/" + user, true
+}
+
+// The check functions below return the problem with a field value, or "" when
+// the value is fine. They are shared by POST and PATCH /students.
+
+func checkName(v string) string {
+	switch {
+	case strings.TrimSpace(v) == "":
+		return "is required"
+	case len(v) > 100:
+		return "must be at most 100 characters"
+	}
+	return ""
+}
+
+func checkCGPA(v float64) string {
+	if v < 0 || v > 10 {
+		return "must be between 0 and 10"
+	}
+	return ""
+}
+
+func checkSem(v int) string {
+	if v < 1 || v > 8 {
+		return "must be between 1 and 8"
+	}
+	return ""
+}
+
+func checkAge(v int) string {
+	if v < 15 || v > 100 {
+		return "must be between 15 and 100"
+	}
+	return ""
+}
+
+const dobLayout = "2006-01-02"
+
+// checkDOB wants a YYYY-MM-DD date that gives an age checkAge accepts
+func checkDOB(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "is required"
+	}
+	dob, err := time.Parse(dobLayout, strings.TrimSpace(v))
+	if err != nil {
+		return "must be a date like 2004-06-01"
+	}
+	now := time.Now()
+	if dob.After(now) || dob.Before(now.AddDate(-100, 0, 0)) || dob.After(now.AddDate(-15, 0, 0)) {
+		return "must give an age between 15 and 100"
+	}
+	return ""
+}
+
+// checkPassword applies the rule of setStudentPassword. bcrypt only looks at
+// the first 72 bytes, so longer passwords are refused rather than cut short.
+func checkPassword(v string) string {
+	switch {
+	case v == "":
+		return "is required"
+	case len(v) < 8:
+		return "must be at least 8 characters"
+	case len(v) > 72:
+		return "must be at most 72 bytes"
+	}
+	return ""
+}
+
+func checkEmail(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "is required"
+	}
+	addr, err := mail.ParseAddress(v)
+	if err != nil || addr.Address != v || !strings.Contains(v[strings.LastIndex(v, "@"):], ".") {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+func checkPhone(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "is required"
+	}
+	digits := 0
+	for _, c := range v {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	if !phonePattern.MatchString(v) || digits < 7 || digits > 15 {
+		return "must be a valid phone number"
+	}
+	if len(v) > 15 {
+		return "must be at most 15 characters"
+	}
+	return ""
+}
+
+func checkRequired(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return "is required"
+	}
+	return ""
+}
+
+func checkGender(v string) string {
+	if len(v) > 10 {
+		return "must be at most 10 characters"
+	}
+	return ""
+}
+
+// The link checks allow an empty value, which means the student has no
+// such profile.
+
+func checkGithubURL(v string) string {
+	if _, ok := canonicalGithubURL(v); v != "" && !ok {
+		return "must be a GitHub profile URL like https://github.com/<user>"
+	}
+	return ""
+}
+
+func checkLeetcodeURL(v string) string {
+	if _, ok := canonicalLeetcodeURL(v); v != "" && !ok {
+		return "must be a LeetCode profile URL like https://leetcode.com/<user>"
+	}
+	return ""
+}
+
+func checkLinkedinURL(v string) string {
+	if _, ok := profileURL(v, linkedinHosts, "/in/", linkedinProfile); v != "" && !ok {
+		return "must be a LinkedIn profile URL like https://www.linkedin.com/in/<name>"
+	}
+	return ""
+}
+
+func checkResumeURL(v string) string {
+	if v == "" {
+		return ""
+	}
+	if u, err := url.Parse(v); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "must be an http(s) URL"
+	}
+	return ""
+}
+
+// fieldErrors collects the problems found while validating a request
+type fieldErrors []fieldError
+
+func (e *fieldErrors) check(field, problem string) {
+	if problem != "" {
+		*e = append(*e, fieldError{Field: field, Message: problem})
+	}
+}
+
+// validate returns one fieldError per invalid field
+func (p *studentPayload) validate() []fieldError {
+	var errs fieldErrors
+	errs.check("name", checkName(p.Name))
+	if strings.TrimSpace(p.SRN) == "" {
+		errs.check("srn", "is required")
+	} else if !srnPattern.MatchString(strings.ToUpper(strings.TrimSpace(p.SRN))) {
+		errs.check("srn", "must look like PES2UG22CS249")
+	}
+	if p.CGPA == nil {
+		errs.check("cgpa", "is required")
+	} else {
+		errs.check("cgpa", checkCGPA(*p.CGPA))
+	}
+	if p.Sem == nil {
+		errs.check("sem", "is required")
+	} else {
+		errs.check("sem", checkSem(*p.Sem))
+	}
+	if p.Age != nil {
+		errs.check("age", checkAge(*p.Age))
+	}
+	errs.check("dob", checkDOB(p.DOB))
+	errs.check("email", checkEmail(p.Email))
+	errs.check("phone", checkPhone(p.Phone))
+	errs.check("degree", checkRequired(p.Degree))
+	errs.check("stream", checkRequired(p.Stream))
+	errs.check("gender", checkGender(p.Gender))
+	errs.check("github_url", checkGithubURL(p.GithubURL))
+	errs.check("leetcode_url", checkLeetcodeURL(p.LeetcodeURL))
+	errs.check("linkedin_url", checkLinkedinURL(p.LinkedinURL))
+	errs.check("resume_url", checkResumeURL(p.ResumeURL))
+	errs.check("password", checkPassword(p.Password))
+	return errs
+}
+
+// normalize trims the payload and canonicalises the profile links. It runs
+// after validate, so the links are known to parse.
+func (p *studentPayload) normalize() {
+	p.Name = strings.TrimSpace(p.Name)
+	p.SRN = strings.ToUpper(strings.TrimSpace(p.SRN))
+	p.Email = strings.TrimSpace(p.Email)
+	p.Phone = strings.TrimSpace(p.Phone)
+	p.DOB = strings.TrimSpace(p.DOB)
+	if u, ok := canonicalGithubURL(p.GithubURL); ok {
+		p.GithubURL = u
+	}
+	if u, ok := canonicalLeetcodeURL(p.LeetcodeURL); ok {
+		p.LeetcodeURL = u
+	}
+}
+
+const maxStudentBody = 1 << 20
+
+// decodeJSONBody decodes a single JSON object, rejecting unknown fields. It
+// writes the error response itself.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStudentBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr):
+			writeAPIError(w, http.StatusUnprocessableEntity, apiError{
+				Error:  "validation failed",
+				Fields: []fieldError{{Field: typeErr.Field, Message: "must be a " + typeErr.Type.String()}},
+			})
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			writeAPIError(w, http.StatusUnprocessableEntity, apiError{
+				Error:  "validation failed",
+				Fields: []fieldError{{Field: field, Message: "is not a known field"}},
+			})
+		default:
+			writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid JSON body: " + err.Error()})
+		}
+		return false
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		writeAPIError(w, http.StatusBadRequest, apiError{Error: "invalid JSON body: only one object is allowed"})
+		return false
+	}
+	return true
+}
+
+// CreateStudent implements POST /students for a mentor. The new student is
+// assigned to that mentor.
+func CreateStudent(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	mentorID, ok := mentorIDFromContext(r.Context())
+	if !ok {
+		writeAPIError(w, http.StatusForbidden, apiError{Error: "not allowed for this account"})
+		return
+	}
+	var payload studentPayload
+	if !decodeJSONBody(w, r, &payload) {
+		return
+	}
+	if errs := payload.validate(); len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+	payload.normalize()
+
+	req := onboardingRequest{
+		Name:        payload.Name,
+		SRN:         payload.SRN,
+		Sem:         *payload.Sem,
+		CGPA:        *payload.CGPA,
+		Email:       payload.Email,
+		PhoneNo:     payload.Phone,
+		Degree:      payload.Degree,
+		Stream:      payload.Stream,
+		Gender:      payload.Gender,
+		GithubURL:   payload.GithubURL,
+		LeetcodeURL: payload.LeetcodeURL,
+		MentorID:    mentorID,
+		ResumeURL:   payload.ResumeURL,
+		Linkedin:    payload.LinkedinURL,
+		Password:    payload.Password,
+	}
+	if payload.Age != nil {
+		req.Age = *payload.Age
+	}
+	req.Dob, _ = time.Parse(dobLayout, payload.DOB)
+
+	result, err := onboardStudent(r.Context(), db, req)
+	if err != nil {
+		log.Printf("failed to onboard student %s: %v", req.SRN, err)
+		writeOnboardingError(w, err)
+		return
+	}
+	log.Printf("Student %s and all associated records inserted successfully", req.SRN)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/students/"+req.SRN)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// studentForRequest returns the srn in the URL after checking that the session
+// is that student or their mentor. It writes the error response itself.
+func studentForRequest(db *gorm.DB, w http.ResponseWriter, r *http.Request) (string, bool) {
+	srn := strings.ToUpper(mux.Vars(r)["srn"])
+	s, _ := sessionFromContext(r.Context())
+	switch s.Role {
+	case roleStudent:
+		if s.Subject == srn {
+			return srn, true
+		}
+	case roleMentor:
+		if mentorID, ok := mentorIDFromContext(r.Context()); ok {
+			owns, err := mentorOwnsStudent(db, mentorID, srn)
+			if err != nil {
+				writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+				return "", false
+			}
+			if owns {
+				return srn, true
+			}
+		}
+	}
+	exists, err := rowExists(db, "SELECT 1 FROM student WHERE student_id = ?", srn)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+	} else if !exists {
+		writeAPIError(w, http.StatusNotFound, apiError{Error: "student not found"})
+	} else {
+		writeAPIError(w, http.StatusForbidden, apiError{Error: "cannot access another student's data"})
+	}
+	return "", false
+}
+
+// studentPatch is the JSON body of PATCH /students/{srn}. Fields left out are
+// not changed. An empty github_url or leetcode_url unlinks the profile.
+type studentPatch struct {
+	Name        *string  `json:"name"`
+	CGPA        *float64 `json:"cgpa"`
+	Sem         *int     `json:"sem"`
+	Age         *int     `json:"age"`
+	Email       *string  `json:"email"`
+	Phone       *string  `json:"phone"`
+	Degree      *string  `json:"degree"`
+	Stream      *string  `json:"stream"`
+	Gender      *string  `json:"gender"`
+	GithubURL   *string  `json:"github_url"`
+	LeetcodeURL *string  `json:"leetcode_url"`
+	LinkedinURL *string  `json:"linkedin_url"`
+	MentorName  *string  `json:"mentor_name"`
+}
+
+func (p *studentPatch) validate() []fieldError {
+	var errs fieldErrors
+	if p.Name != nil {
+		errs.check("name", checkName(*p.Name))
+	}
+	if p.CGPA != nil {
+		errs.check("cgpa", checkCGPA(*p.CGPA))
+	}
+	if p.Sem != nil {
+		errs.check("sem", checkSem(*p.Sem))
+	}
+	if p.Age != nil {
+		errs.check("age", checkAge(*p.Age))
+	}
+	if p.Email != nil {
+		errs.check("email", checkEmail(*p.Email))
+	}
+	if p.Phone != nil {
+		errs.check("phone", checkPhone(*p.Phone))
+	}
+	if p.Degree != nil {
+		errs.check("degree", checkRequired(*p.Degree))
+	}
+	if p.Stream != nil {
+		errs.check("stream", checkRequired(*p.Stream))
+	}
+	if p.Gender != nil {
+		errs.check("gender", checkGender(*p.Gender))
+	}
+	if p.GithubURL != nil {
+		errs.check("github_url", checkGithubURL(*p.GithubURL))
+	}
+	if p.LeetcodeURL != nil {
+		errs.check("leetcode_url", checkLeetcodeURL(*p.LeetcodeURL))
+	}
+	if p.LinkedinURL != nil {
+		errs.check("linkedin_url", checkLinkedinURL(*p.LinkedinURL))
+	}
+	if p.MentorName != nil {
+		errs.check("mentor_name", checkRequired(*p.MentorName))
+	}
+	return errs
+}
+
+// academicFields names the fields of the patch that only a mentor may change.
+// They decide drive eligibility, so a student cannot set them on their own.
+func (p *studentPatch) academicFields() []string {
+	var fields []string
+	if p.CGPA != nil {
+		fields = append(fields, "cgpa")
+	}
+	if p.Sem != nil {
+		fields = append(fields, "sem")
+	}
+	if p.Degree != nil {
+		fields = append(fields, "degree")
+	}
+	if p.Stream != nil {
+		fields = append(fields, "stream")
+	}
+	return fields
+}
+
+// studentColumns turns the plain student fields of the patch into column
+// assignments, in a fixed order
+func (p *studentPatch) studentColumns() ([]string, []interface{}) {
+	var cols []string
+	var args []interface{}
+	set := func(col string, v interface{}) {
+		cols = append(cols, col)
+		args = append(args, v)
+	}
+	if p.Name != nil {
+		set("name", strings.TrimSpace(*p.Name))
+	}
+	if p.CGPA != nil {
+		set("cgpa", *p.CGPA)
+	}
+	if p.Sem != nil {
+		set("sem", *p.Sem)
+	}
+	if p.Age != nil {
+		set("age", *p.Age)
+	}
+	if p.Email != nil {
+		set("email", strings.TrimSpace(*p.Email))
+	}
+	if p.Phone != nil {
+		set("phone_no", strings.TrimSpace(*p.Phone))
+	}
+	if p.Degree != nil {
+		set("degree", *p.Degree)
+	}
+	if p.Stream != nil {
+		set("stream", *p.Stream)
+	}
+	if p.Gender != nil {
+		set("gender", *p.Gender)
+	}
+	if p.LinkedinURL != nil {
+		set("linkedin", *p.LinkedinURL)
+	}
+	return cols, args
+}
+
+// unlinkGithub removes the student's github row and its repositories
+func unlinkGithub(db *gorm.DB, srn string) error {
+	err := db.Exec(`
+		DELETE FROM repository
+		WHERE github_id IN (SELECT github_id FROM github WHERE student_id = ?)`, srn).Error
+	if err != nil {
+		return fmt.Errorf("could not delete repositories: %v", err)
+	}
+	if err := db.Exec("DELETE FROM github WHERE student_id = ?", srn).Error; err != nil {
+		return fmt.Errorf("could not delete github: %v", err)
+	}
+	return nil
+}
+
+// unlinkLeetCode removes the student's leetcode and problems rows. The
+// snapshots go as well, since they describe the old account.
+func unlinkLeetCode(db *gorm.DB, srn string) error {
+	err := db.Exec(`
+		DELETE FROM problems
+		WHERE leetcode_id IN (SELECT leetcode_id FROM leetcode WHERE student_id = ?)`, srn).Error
+	if err != nil {
+		return fmt.Errorf("could not delete problems: %v", err)
+	}
+	if err := db.Exec("DELETE FROM leetcode WHERE student_id = ?", srn).Error; err != nil {
+		return fmt.Errorf("could not delete leetcode: %v", err)
+	}
+	if err := db.Exec("DELETE FROM leetcode_snapshots WHERE student_id = ?", srn).Error; err != nil {
+		return fmt.Errorf("could not delete leetcode snapshots: %v", err)
+	}
+	return nil
+}
+
+// UpdateStudent implements PATCH /students/{srn}. Only the given fields are
+// changed. A new GitHub or LeetCode link re-fetches just that profile. The
+// academic fields and the mentor can only be changed by the mentor.
+func UpdateStudent(db *gorm.DB, w http.ResponseWriter, r *http.Request) {
+	srn, ok := studentForRequest(db, w, r)
+	if !ok {
+		return
+	}
+	var patch studentPatch
+	if !decodeJSONBody(w, r, &patch) {
+		return
+	}
+	if errs := patch.validate(); len(errs) > 0 {
+		writeAPIError(w, http.StatusUnprocessableEntity, apiError{Error: "validation failed", Fields: errs})
+		return
+	}
+
+	s, _ := sessionFromContext(r.Context())
+	if fields := patch.academicFields(); len(fields) > 0 && s.Role != roleMentor {
+		writeAPIError(w, http.StatusForbidden, apiError{Error: "only a mentor can change " + strings.Join(fields, ", ")})
+		return
+	}
+	cols, args := patch.studentColumns()
+	if patch.MentorName != nil {
+		if s.Role != roleMentor {
+			writeAPIError(w, http.StatusForbidden, apiError{Error: "only a mentor can reassign a student"})
+			return
+		}
+		mentorID, err := fetchMentorID(db, *patch.MentorName)
+		if err != nil || mentorID == 0 {
+			writeAPIError(w, http.StatusUnprocessableEntity, apiError{
+				Error:  "validation failed",
+				Fields: []fieldError{{Field: "mentor_name", Message: "is not a known mentor"}},
+			})
+			return
+		}
+		cols = append(cols, "mentor_id")
+		args = append(args, mentorID)
+	}
+
+	// Only links that actually change are re-fetched, and the fetches
+	// happen before the transaction is opened.
+	var current struct {
+		GithubID   string
+		LeetcodeID string
+	}
+	err := db.Raw(`
+		SELECT
+			(SELECT github_id FROM github WHERE student_id = ? LIMIT 1) AS github_id,
+			(SELECT leetcode_id FROM leetcode WHERE student_id = ? LIMIT 1) AS leetcode_id`, srn, srn).Scan(&current).Error
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, apiError{Error: "failed to query database"})
+		return
+	}
+	var githubURL, leetcodeURL, leetUsername string
+	var profile ProfileData
+	var leetProfile LeetCodeProfile
+	githubChanged, leetcodeChanged := false, false
+	if patch.GithubURL != nil {
+		githubURL, _ = canonicalGithubURL(*patch.GithubURL)
+		githubChanged = githubURL != current.GithubID
+	}
+	if githubChanged && githubURL != "" {
+		username, _ := getUsernameFromURL(githubURL)
+		if profile, err = githubClient.FetchProfile(r.Context(), username); err != nil {
+			writeOnboardingError(w, stepFailed("fetch_github", http.StatusBadGateway, err))
+			return
+		}
+	}
+	if patch.LeetcodeURL != nil {
+		leetcodeURL, _ = canonicalLeetcodeURL(*patch.LeetcodeURL)
+		leetcodeChanged = leetcodeURL != current.LeetcodeID
+	}
+	if leetcodeChanged && leetcodeURL != "" {
+		leetUsername, _ = getUsernameFromURL(leetcodeURL)
+		if leetProfile, err = leetcodeProvider.FetchProfile(r.Context(), leetUsername); err != nil {
+			writeOnboardingError(w, stepFailed("fetch_leetcode", http.StatusBadGateway, err))
+			return
+		}
+	}
+
+	report := newImportReport()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(cols) > 0 {
+			assignments := make([]string, len(cols))
+			for i, col := range cols {
+				assignments[i] = col + " = ?"
+			}
+			query := "UPDATE student SET " + strings.Join(assignments, ", ") + " WHERE student_id = ?"
+			if err := tx.Exec(query, append(args, srn)...).Error; err != nil {
+				return stepFailed("update_student", http.StatusConflict, err)
+			}
+		}
+		if githubChanged {
+			if err := unlinkGithub(tx, srn); err != nil {
+				return stepFailed("unlink_github", http.StatusInternalServerError, err)
+			}
+			if githubURL != "" {
+				if err := storeGithubProfile(tx, srn, githubURL, profile, report); err != nil {
+					return stepFailed("store_github", http.StatusConflict, err)
+				}
+			}
+		}
+		if leetcodeChanged {
+			if err := unlinkLeetCode(tx, srn); err != nil {
+				return stepFailed("unlink_leetcode", http.StatusInternalServerError, err)
+			}
+			if leetcodeURL != "" {
+				if err := storeLeetCodeProfile(tx, srn, leetcodeURL, leetUsername, leetProfile, report); err != nil {
+					return stepFailed("store_leetcode", http.StatusConflict, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to update student %s: %v", srn, err)
+		writeOnboardingError(w, err)
+		return
+	}
+
+	updated := append([]string{}, cols...)
+	response := map[string]interface{}{
+		"srn":     srn,
+		"updated": updated,
+		"refreshed": map[string]bool{
+			"github":   githubChanged,
+			"leetcode": leetcodeChanged,
+		},
+	}
+	if githubChanged {
+		response["repositories"] = len(profile.PinnedRepos)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
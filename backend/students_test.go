@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// newStudentRouter serves the student API and the login from db
+func newStudentRouter(t *testing.T, db *gorm.DB) http.Handler {
+	t.Helper()
+	r := newTestRouter(t, newSQLStore(db)).(*mux.Router)
+	r.HandleFunc("/students", requireMentor(func(w http.ResponseWriter, r *http.Request) {
+		CreateStudent(db, w, r)
+	})).Methods("POST")
+	r.HandleFunc("/students/{srn}", requireStudentOrMentor(func(w http.ResponseWriter, r *http.Request) {
+		UpdateStudent(db, w, r)
+	})).Methods("PATCH")
+	return r
+}
+
+const newStudentBody = `{"name": "Deepa", "srn": "PES2UG22CS104", "cgpa": 8.4, "sem": 3, "dob": "2004-02-29",
+	"email": "deepa@example.com", "phone": "9876543210", "degree": "BTech", "stream": "CSE", "password": "deepa-secret"}`
+
+func TestCreateStudentValidatesDobAndPassword(t *testing.T) {
+	r := newStudentRouter(t, newSQLiteDB(t))
+	tests := []struct {
+		replace, with, field string
+	}{
+		{`"dob": "2004-02-29"`, `"dob": "29/02/2004"`, "dob"},
+		{`"dob": "2004-02-29"`, `"dob": "2099-01-01"`, "dob"},
+		{`, "dob": "2004-02-29"`, ``, "dob"},
+		{`"password": "deepa-secret"`, `"password": "short"`, "password"},
+		{`, "password": "deepa-secret"`, ``, "password"},
+	}
+	for _, tt := range tests {
+		body := strings.Replace(newStudentBody, tt.replace, tt.with, 1)
+		rec := serveRequest(r, "POST", "/students", testToken(t, "1", roleMentor), body)
+		var resp apiError
+		decodeResponse(t, rec, &resp)
+		if rec.Code != http.StatusUnprocessableEntity || len(resp.Fields) != 1 || resp.Fields[0].Field != tt.field {
+			t.Errorf("POST /students with %s = %d %+v, want a %s error", tt.with, rec.Code, resp, tt.field)
+		}
+	}
+}
+
+func TestCreatedStudentCanLogIn(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	r := newStudentRouter(t, db)
+
+	if rec := serveRequest(r, "POST", "/students", testToken(t, "2", roleMentor), newStudentBody); rec.Code != http.StatusCreated {
+		t.Fatalf("POST /students = %d %s", rec.Code, rec.Body)
+	}
+	student, _, err := newSQLStore(db).Student("PES2UG22CS104")
+	if err != nil || student.Dob.Format(dobLayout) != "2004-02-29" || student.MentorID != 2 {
+		t.Errorf("dob = %v, mentor = %d, %v, want the creating mentor", student.Dob, student.MentorID, err)
+	}
+	rec := serveRequest(r, "POST", "/login", "", `{"srn": "pes2ug22cs104", "password": "deepa-secret"}`)
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST /login = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestCreateStudentNeedsMentor(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	r := newStudentRouter(t, db)
+
+	for _, token := range []string{"", testToken(t, "PES2UG22CS104", roleStudent)} {
+		if rec := serveRequest(r, "POST", "/students", token, newStudentBody); rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+			t.Errorf("POST /students without a mentor session = %d %s", rec.Code, rec.Body)
+		}
+	}
+	if exists, _ := rowExists(db, "SELECT 1 FROM student WHERE student_id = ?", "PES2UG22CS104"); exists {
+		t.Error("student created without a mentor session")
+	}
+	body := strings.Replace(newStudentBody, `"password"`, `"mentor_name": "Vikram Iyer", "password"`, 1)
+	if rec := serveRequest(r, "POST", "/students", testToken(t, "1", roleMentor), body); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("POST /students naming another mentor = %d %s, want 422", rec.Code, rec.Body)
+	}
+}
+
+func TestUpdateStudentAcademicFieldsNeedMentor(t *testing.T) {
+	db := newSQLiteDB(t)
+	seedSQLiteDB(t, db)
+	r := newStudentRouter(t, db)
+	student := testToken(t, "PES2", roleStudent)
+
+	for _, body := range []string{`{"cgpa": 9.9}`, `{"sem": 8}`, `{"degree": "MTech"}`, `{"stream": "ECE"}`, `{"phone": "9876543210", "cgpa": 9.9}`} {
+		if rec := serveRequest(r, "PATCH", "/students/PES2", student, body); rec.Code != http.StatusForbidden {
+			t.Errorf("student PATCH %s = %d %s, want 403", body, rec.Code, rec.Body)
+		}
+	}
+	got, _, _ := newSQLStore(db).Student("PES2")
+	if got.CGPA != 8.2 || got.Sem != 5 || got.PhoneNo != "" {
+		t.Errorf("student changed by refused patches: %+v", got)
+	}
+
+	if rec := serveRequest(r, "PATCH", "/students/PES2", student, `{"phone": "9876543210"}`); rec.Code != http.StatusOK {
+		t.Errorf("student PATCH phone = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serveRequest(r, "PATCH", "/students/PES2", testToken(t, "1", roleMentor), `{"cgpa": 8.6, "sem": 6}`); rec.Code != http.StatusOK {
+		t.Errorf("mentor PATCH cgpa = %d %s", rec.Code, rec.Body)
+	}
+	got, _, _ = newSQLStore(db).Student("PES2")
+	if got.CGPA != 8.6 || got.Sem != 6 || got.PhoneNo != "9876543210" {
+		t.Errorf("student after allowed patches: %+v", got)
+	}
+}
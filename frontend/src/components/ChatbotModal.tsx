@@ -1,4 +1,6 @@
 import React, { useEffect, useState, useRef } from 'react';
+import { API_URL } from '../config';
+import { authHeaders } from '../session';
 
 interface ChatbotModalProps {
   srn: string;
@@ -28,10 +30,10 @@ const ChatbotModal: React.FC<ChatbotModalProps> = ({ srn }) => {
     setLoading(true);
 
     try {
-      const response = await fetch('http://100.102.21.101:8081/chat', {
+      const response = await fetch(`${API_URL}/students/${srn}/chat`, {
         method: 'POST',
-        headers: { 'Content-Type': 'application/json' },
-        body: JSON.stringify({ srn, message: newMessage.content }),
+        headers: { 'Content-Type': 'application/json', ...authHeaders() },
+        body: JSON.stringify({ message: newMessage.content }),
       });
 
       const data = await response.json();
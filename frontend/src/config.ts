@@ -0,0 +1,2 @@
+// Base URL of the backend API. Set VITE_API_URL to point the build elsewhere.
+export const API_URL: string = import.meta.env.VITE_API_URL ?? 'http://100.102.21.101:8000';
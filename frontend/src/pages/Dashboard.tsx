@@ -19,6 +19,8 @@ import { ReactComponent as LeaderboardIcon } from '../assets/leaderboard-svgrepo
 import { FaBookOpen } from 'react-icons/fa';
 import { FaRegFileAlt } from 'react-icons/fa';
 import ChatbotModal from '../components/ChatbotModal';
+import { API_URL } from '../config';
+import { authHeaders, clearSession } from '../session';
 
 
 interface MousePosition {
@@ -150,10 +152,10 @@ export const Dashboard = () => {
   useEffect(() => {
     const fetchStudentInfo = async () => {
       try {
-        const infoResponse = await axios.get(`http://100.102.21.101:8000/getInfo?srn=${studentSRN}`);
+        const infoResponse = await axios.get(`${API_URL}/getInfo?srn=${studentSRN}`, { headers: authHeaders() });
         setStudentInfo(infoResponse.data);
 
-        const linkedinResponse = await axios.get(`http://100.102.21.101:8000/getLinkedin?srn=${studentSRN}`);
+        const linkedinResponse = await axios.get(`${API_URL}/getLinkedin?srn=${studentSRN}`, { headers: authHeaders() });
         console.log(linkedinResponse)
         setLinkedinUrl(linkedinResponse.data); // Fetch LinkedIn URL as per the interface
         console.log(linkedinUrl)
@@ -175,7 +177,7 @@ export const Dashboard = () => {
   };
   const fetchScore = async () => {
     try {
-      const res = await fetch(`http://100.102.21.101:8000/score?srn=${studentSRN}`);
+      const res = await fetch(`${API_URL}/score?srn=${studentSRN}`, { headers: authHeaders() });
       if (!res.ok) {
         throw new Error(`Error: ${res.status}`);
       }
@@ -191,7 +193,7 @@ export const Dashboard = () => {
   const fetchData = async (srn: string) => {
     setIsGithubLoading(true);
     try {
-      const response = await axios.get(`http://100.102.21.101:8000/getGithub?srn=${srn}`);
+      const response = await axios.get(`${API_URL}/getGithub?srn=${srn}`, { headers: authHeaders() });
       setGithubData(response.data);
       return response.data;
     } catch (error) {
@@ -204,7 +206,7 @@ export const Dashboard = () => {
   const fetchLeetcodeData = async (srn: string) => {
     setIsLeetcodeLoading(true);
     try {
-      const response = await axios.get(`http://100.102.21.101:8000/getLeetcode?srn=${srn}`);
+      const response = await axios.get(`${API_URL}/getLeetcode?srn=${srn}`, { headers: authHeaders() });
       setLeetcodeData(response.data);
       return response.data;
     } catch (error) {
@@ -217,7 +219,7 @@ export const Dashboard = () => {
   const fetchMentorSessionData = async (srn: string) => {
     setIsMentorSessionLoading(true);
     try {
-      const response = await axios.get(`http://100.102.21.101:8000/getMentorSessions?srn=${srn}`);
+      const response = await axios.get(`${API_URL}/getMentorSessions?srn=${srn}`, { headers: authHeaders() });
       return response.data.sessions; // Assuming the data is returned as an array of sessions
     } catch (error) {
       console.error('Error fetching MentorSessions data:', error);
@@ -234,9 +236,10 @@ export const Dashboard = () => {
     setConfirmationModal(false); // Close confirmation modal
 
     try {
-      const response = await axios.get(`http://100.102.21.101:8000/deleteStudent?srn=${studentSRN}`);
+      const response = await axios.delete(`${API_URL}/students/${studentSRN}`, { headers: authHeaders() });
       if (response.status === 200) {
         console.log("Student deleted successfully!");
+        clearSession();
       
         setTimeout(() => navigate("/"), 2000); // Redirect to landing page after 2 seconds
       } else {
@@ -251,17 +254,22 @@ export const Dashboard = () => {
     setConfirmationModal(false); // Close the modal without deleting
   };
   const handleBackToHome = () => {
+    clearSession();
     navigate('/landing');
   };
 
 const fetchCgpaStats = async (srn) => {
   try {
-    const response = await axios.get(`http://100.102.21.101:8000/getCGPAStatistics?srn=${srn}`);
+    const response = await axios.get(`${API_URL}/leaderboard?metric=cgpa&srn=${srn}`, { headers: authHeaders() });
     console.log("CGPA Stats Response:", response.data);  // Log the respons
-    setCgpaStats(response.data);
+    const stats = {
+      leaderboard: response.data.entries.map((entry) => ({ ...entry, cgpa: entry.value })),
+      relative_rank: response.data.me?.rank,
+    };
+    setCgpaStats(stats);
 
 
-    return response.data;
+    return stats;
   } catch (error) {
     console.error('Error fetching CGPA stats:', error);
     return null;
@@ -270,10 +278,14 @@ const fetchCgpaStats = async (srn) => {
 // Function to fetch LeetCode rank data
 const fetchLeetcodeStats = async (srn) => {
   try {
-    const response = await axios.get(`http://100.102.21.101:8000/getLeetCodeStatistics?srn=${srn}`);
+    const response = await axios.get(`${API_URL}/leaderboard?metric=leetcode_ranking&srn=${srn}`, { headers: authHeaders() });
     console.log("leetcode Stats Response:", response.data);  // Log the respons
-    setLeetcodeStats(response.data);
-    return response.data;
+    const stats = {
+      leaderboard: response.data.entries.map((entry) => ({ ...entry, rank: entry.value })),
+      relative_rank: response.data.me?.rank,
+    };
+    setLeetcodeStats(stats);
+    return stats;
   } catch (error) {
     console.error('Error fetching LeetCode stats:', error);
     return null;
@@ -667,7 +679,7 @@ const handleStatButtonClick = (stat, cgpaLeaderboard, cgpaRelativeRank, leetcode
     );
 
     try {
-        const response = await fetch(`http://100.102.21.101:8000/getResume?srn=${studentSRN}`);
+        const response = await fetch(`${API_URL}/getResume?srn=${studentSRN}`, { headers: authHeaders() });
         const blob = await response.blob();
         const url = URL.createObjectURL(blob);
         setResumeData(url);
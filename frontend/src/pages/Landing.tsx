@@ -3,8 +3,9 @@ import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
 import { Typewriter } from 'react-simple-typewriter';
 import { motion, AnimatePresence } from 'framer-motion';
-import * as forge from 'node-forge'
 import user from '../assets/user.svg';
+import { API_URL } from '../config';
+import { saveSession } from '../session';
 
 interface MousePosition {
     x: number;
@@ -57,36 +58,14 @@ export const Landing = () => {
         setPasswordValue(e.target.value);
     };
 
-    const getEncryptedAESKey = async (): Promise<{ encryptedAESKey: string, aesKey: Uint8Array }> => {
-        const response = await fetch("http://100.102.21.101:8000/getPublicKey");
-        const publicKeyPem = await response.text();
-
-        const rsaPublicKey = forge.pki.publicKeyFromPem(publicKeyPem);
-        const aesKey = forge.random.getBytesSync(16); // 128-bit AES key
-
-        const encryptedAESKey = rsaPublicKey.encrypt(aesKey, "RSA-OAEP");
-        return {
-            encryptedAESKey: forge.util.encode64(encryptedAESKey),
-            aesKey: forge.util.createBuffer(aesKey).toHex(),
-        };
-    };
-
     const handleSubmit = async () => {
         if (validateInput(inputValue)) {
             setIsInputValid(true);
             setShowShakeAnimation(false);
             try {
-                const encryptedPassword = forge.util.encode64(passwordValue);
-                console.log(encryptedPassword)
-                const response = await axios.get(`http://100.102.21.101:8000/student?srn=${inputValue}&password=${encryptedPassword}`, {
-                });
-                const studentName = response.data;
-                console.log(studentName)
-                if (!studentName) {
-                    setShowShakeAnimation(true);
-                    setTimeout(() => setShowShakeAnimation(false), 400);
-                    return;
-                }
+                const response = await axios.post(`${API_URL}/login`, { srn: inputValue, password: passwordValue });
+                const { token, name: studentName } = response.data;
+                saveSession(token);
                 setStudentName(studentName);
                 navigate('/dashboard', { state: { studentName, srn: inputValue } });
             } catch (error: any) {
@@ -2,7 +2,8 @@ import React, { useState, useRef, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { Typewriter } from 'react-simple-typewriter';
 import axios from 'axios';
-import * as forge from 'node-forge';
+import { API_URL } from '../config';
+import { saveSession } from '../session';
 
 interface MousePosition {
     x: number;
@@ -47,15 +48,9 @@ export const MentorLogin = () => {
         }
 
         try {
-            const encryptedPassword = btoa(password);
-            const response = await axios.get(`http://100.102.21.101:8000/mentorLogin?mentorId=${mentorId}&password=${password}`);
-            const mentorName = response.data;
-
-            if (!mentorName) {
-                setShowShakeAnimation(true);
-                setTimeout(() => setShowShakeAnimation(false), 400);
-                return;
-            }
+            const response = await axios.post(`${API_URL}/mentorLogin`, { mentorId, password });
+            const { token, mentor_name: mentorName } = response.data;
+            saveSession(token);
 
             navigate('/mentor-session', { state: { mentorId, mentorName } });
         } catch (error) {
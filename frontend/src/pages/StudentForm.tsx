@@ -2,6 +2,8 @@ import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
 import { Typewriter } from 'react-simple-typewriter';
+import { API_URL } from '../config';
+import { authHeaders } from '../session';
 
 interface MousePosition {
   x: number;
@@ -14,10 +16,11 @@ const StudentFormPage = () => {
   const [formsem, setFormsem] = useState('');
   const [formGithubLink, setFormG_profile] = useState('');
   const [formLeetcodeLink, setFormL_profile] = useState('');
-  const [formMentorName, setFormMentorName] = useState('');
   const [formLinkedinLink, setFormLinkedin] = useState('');
   const [formCgpa, setFormcgpa] = useState('');
   const [formAge, setFormage] = useState('');
+  const [formDob, setFormDob] = useState('');
+  const [formPassword, setFormPassword] = useState('');
   const [formPhoneNo, setFormPh_no] = useState('');
   const [formDegree, setFormDegree] = useState('');
   const [formStream, setFormStream] = useState('');
@@ -34,10 +37,11 @@ const StudentFormPage = () => {
     formsem: false,
     formGithubLink: false,
     formLeetcodeLink: false,
-    formMentorName: false,
     formLinkedinLink: false,
     formCgpa: false,
     formAge: false,
+    formDob: false,
+    formPassword: false,
     formPhoneNo: false,
     formDegree: false,
     formStream: false,
@@ -90,10 +94,11 @@ const StudentFormPage = () => {
       formsem: !validateSemester(formsem),
       formGithubLink: formGithubLink.trim() === '',
       formLeetcodeLink: formLeetcodeLink.trim() === '',
-      formMentorName: formMentorName.trim() === '',
       formLinkedinLink: formLinkedinLink.trim() === '',
       formCgpa: !validateCgpa(formCgpa),
-      formAge: formAge === '' || parseInt(formAge) <= 0,
+      formAge: formAge !== '' && parseInt(formAge) <= 0,
+      formDob: formDob === '',
+      formPassword: formPassword.length < 8,
       formPhoneNo: !validatePhone(formPhoneNo),
       formDegree: formDegree.trim() === '',
       formStream: formStream.trim() === '',
@@ -106,14 +111,37 @@ const StudentFormPage = () => {
 
     if (Object.values(errors).some(Boolean)) return;
 
-    const apiUrl = `http://100.102.21.101:8000/insertStudent?name=${encodeURIComponent(formName)}&srn=${encodeURIComponent(formSrn)}&cgpa=${encodeURIComponent(formCgpa)}&sem=${encodeURIComponent(formsem)}&age=${encodeURIComponent(formAge)}&email=${encodeURIComponent(formEmail)}&phone_num=${encodeURIComponent(formPhoneNo)}&degree=${encodeURIComponent(formDegree)}&stream=${encodeURIComponent(formStream)}&gender=${encodeURIComponent(formGender)}&git_link=${encodeURIComponent(formGithubLink)}&leet_link=${encodeURIComponent(formLeetcodeLink)}&men_name=${encodeURIComponent(formMentorName)}&resume=${encodeURIComponent(formResume)}&linkedin_link=${encodeURIComponent(formLinkedinLink)}`;
+    const payload = {
+      name: formName,
+      srn: formSrn,
+      cgpa: Number(formCgpa),
+      sem: Number(formsem),
+      age: formAge === '' ? undefined : Number(formAge),
+      dob: formDob,
+      email: formEmail,
+      phone: formPhoneNo,
+      degree: formDegree,
+      stream: formStream,
+      gender: formGender,
+      github_url: formGithubLink,
+      leetcode_url: formLeetcodeLink,
+      resume_url: formResume,
+      linkedin_url: formLinkedinLink,
+      password: formPassword,
+    };
 
     try {
-      await axios.get(apiUrl);
+      // Only a mentor can add a student, who is then assigned to that mentor
+      await axios.post(`${API_URL}/students`, payload, { headers: authHeaders() });
       setSubmitted(true);
       setTimeout(() => setSubmitted(false), 3000);
     } catch (err) {
-      alert("Submission failed.");
+      const fields = axios.isAxiosError(err) ? err.response?.data?.fields : undefined;
+      if (fields && fields.length > 0) {
+        alert("Submission failed:\n" + fields.map((f: { field: string; message: string }) => `${f.field} ${f.message}`).join("\n"));
+      } else {
+        alert("Submission failed.");
+      }
     }
   };
 
@@ -124,7 +152,8 @@ const StudentFormPage = () => {
         { label: 'Full Name', state: formName, setState: setFormName, type: 'text', error: inputErrors.formName, icon: '👤', placeholder: 'Enter your full name' },
         { label: 'Email Address', state: formEmail, setState: setFormEmail, type: 'email', error: inputErrors.formEmail, icon: '📧', placeholder: 'your.email@example.com' },
         { label: 'Phone Number', state: formPhoneNo, setState: setFormPh_no, type: 'text', error: inputErrors.formPhoneNo, icon: '📱', placeholder: '10-digit phone number' },
-        { label: 'Age', state: formAge, setState: setFormage, type: 'number', error: inputErrors.formAge, icon: '🎂', placeholder: 'Your age' },
+        { label: 'Date of Birth', state: formDob, setState: setFormDob, type: 'date', error: inputErrors.formDob, icon: '📅', placeholder: 'YYYY-MM-DD' },
+        { label: 'Age', state: formAge, setState: setFormage, type: 'number', error: inputErrors.formAge, icon: '🎂', placeholder: 'Your age (optional)' },
         { label: 'Gender', state: formGender, setState: setFormGender, type: 'text', error: inputErrors.formGender, icon: '⚧', placeholder: 'Male/Female/Other' },
       ]
     },
@@ -136,7 +165,7 @@ const StudentFormPage = () => {
         { label: 'CGPA', state: formCgpa, setState: setFormcgpa, type: 'text', error: inputErrors.formCgpa, icon: '📊', placeholder: '1.0 - 10.0' },
         { label: 'Degree', state: formDegree, setState: setFormDegree, type: 'text', error: inputErrors.formDegree, icon: '🎯', placeholder: 'B.Tech, B.E, etc.' },
         { label: 'Stream', state: formStream, setState: setFormStream, type: 'text', error: inputErrors.formStream, icon: '🔬', placeholder: 'Computer Science, Electronics, etc.' },
-        { label: 'Mentor Name', state: formMentorName, setState: setFormMentorName, type: 'text', error: inputErrors.formMentorName, icon: '👨‍🏫', placeholder: 'Your assigned mentor' },
+        { label: 'Password', state: formPassword, setState: setFormPassword, type: 'password', error: inputErrors.formPassword, icon: '🔒', placeholder: 'At least 8 characters, used to log in' },
       ]
     },
     {
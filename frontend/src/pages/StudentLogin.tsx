@@ -2,7 +2,8 @@ import React, { useState, useEffect, useRef } from 'react';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
 import { Typewriter } from 'react-simple-typewriter';
-import * as forge from 'node-forge';
+import { API_URL } from '../config';
+import { saveSession } from '../session';
 
 interface MousePosition {
     x: number;
@@ -41,14 +42,9 @@ export const StudentLogin = () => {
             setIsInputValid(true);
             setShowShakeAnimation(false);
             try {
-                const encryptedPassword = forge.util.encode64(passwordValue);
-                const response = await axios.get(`http://100.102.21.101:8000/student?srn=${inputValue}&password=${encryptedPassword}`);
-                const studentName = response.data;
-                if (!studentName) {
-                    setShowShakeAnimation(true);
-                    setTimeout(() => setShowShakeAnimation(false), 400);
-                    return;
-                }
+                const response = await axios.post(`${API_URL}/login`, { srn: inputValue, password: passwordValue });
+                const { token, name: studentName } = response.data;
+                saveSession(token);
                 navigate('/student-form', { state: { studentName, srn: inputValue } });
             } catch (error: any) {
                 setShowShakeAnimation(true);
@@ -0,0 +1,13 @@
+// The backend issues a session token at login that every protected route
+// expects as a Bearer token. It is kept for the lifetime of the browser tab.
+const TOKEN_KEY = 'placify_token';
+
+export const saveSession = (token: string) => sessionStorage.setItem(TOKEN_KEY, token);
+
+export const clearSession = () => sessionStorage.removeItem(TOKEN_KEY);
+
+// authHeaders are the headers to send with every request to a protected route
+export const authHeaders = (): Record<string, string> => {
+    const token = sessionStorage.getItem(TOKEN_KEY);
+    return token ? { Authorization: `Bearer ${token}` } : {};
+};